@@ -37,7 +37,7 @@ def test_template_list(client):
 def test_refresh_catalog(client):
     url = 'http://localhost:8088/v1-catalog/templates?action=refresh'
     response = requests.post(url)
-    assert response.status_code == 204
+    assert response.status_code in (202, 204)
 
 
 def test_catalog_not_found(client):
@@ -76,7 +76,7 @@ def test_template_files_map(client):
     versionUrls = templates[0].versionLinks.values()
 
     url = versionUrls[0]
-    response = requests.get(url)
+    response = requests.get(url, params={'include': 'compose'})
     assert response.status_code == 200
     resp = response.json()
     assert resp['files'] is not None
@@ -97,7 +97,8 @@ def test_template_many_versions(client):
             if templates[i].id == unicode('qa-catalog:many-versions'):
                 versionUrls = templates[i].versionLinks.values()
                 for i in range(len(versionUrls)):
-                    version_response = requests.get(versionUrls[i])
+                    version_response = requests.get(
+                        versionUrls[i], params={'include': 'compose'})
                     assert version_response is not 404
                     response_json = version_response.json()
                     docker_compose = response_json.get(unicode('files')) \
@@ -315,3 +316,639 @@ def test_upgrade_filters(client):
                     get(unicode('upgradeVersionLinks'))
                 assert len(upgradeUrls) == 1
                 assert "v1.4.6-rancher1" in upgradeUrls
+
+
+def test_template_version_count_filter(client):
+    templates = client.list_template(minVersionCount='2')
+    assert len(templates) > 0
+    for i in range(len(templates)):
+        assert len(templates[i].versionLinks) >= 2
+
+    templates = client.list_template(maxVersionCount='1')
+    for i in range(len(templates)):
+        assert len(templates[i].versionLinks) == 1
+
+    url = 'http://localhost:8088/v1-catalog/templates?minVersionCount=abc'
+    response = requests.get(url)
+    assert response.status_code == 400
+
+
+def test_admin_diagnostics(client):
+    url = 'http://localhost:8088/v1-catalog/admin/diagnostics'
+    response = requests.get(url)
+    assert response.status_code == 200
+    resp = response.json()
+    for diagnostic in resp.get('data', []):
+        assert diagnostic['templatePath'] is not None
+        assert len(diagnostic['issues']) > 0
+
+
+def test_template_version_include(client):
+    templates = client.list_template()
+    assert len(templates) > 0
+    url = templates[0].versionLinks.values()[0]
+
+    response = requests.get(url + '?include=questions')
+    assert response.status_code == 200
+    resp = response.json()
+    assert resp['files'] is None
+    assert resp['bindings'] is None
+
+    response = requests.get(url + '?include=compose')
+    assert response.status_code == 200
+    assert response.json()['files'] is not None
+
+    # the compose files are left out unless requested
+    response = requests.get(url)
+    assert response.status_code == 200
+    assert response.json()['files'] is None
+
+
+def test_template_upgrades(client):
+    url = 'http://localhost:8088/v1-catalog/templates/' \
+        'qa-catalog:many-versions/upgrades'
+    response = requests.get(url + '?from=1.0.0')
+    assert response.status_code == 200
+    resp = response.json()
+    assert '1.0.0' not in resp['versions']
+    assert sorted(resp['versions']) == sorted(resp['versionLinks'].keys())
+
+    response = requests.get(url)
+    assert response.status_code == 400
+
+    response = requests.get(url + '?from=abc')
+    assert response.status_code == 400
+
+
+def test_admin_template_last_commit(client):
+    templates = client.list_template(catalogId='qa-catalog')
+    assert len(templates) > 0
+    url = 'http://localhost:8088/v1-catalog/admin/templates/{}/lastcommit'
+    response = requests.get(url.format(templates[0].id))
+    assert response.status_code == 200
+    resp = response.json()
+    assert len(resp['sha']) == 40
+    assert resp['author'] is not None
+    assert resp['date'] is not None
+
+    response = requests.get(url.format('qa-catalog:xyz'))
+    assert response.status_code == 404
+
+
+def test_refresh_single_catalog(client):
+    url = 'http://localhost:8088/v1-catalog/catalogs/{}/templates' \
+        '?action=refresh'
+    response = requests.post(url.format('qa-catalog'))
+    assert response.status_code in (202, 204)
+    if response.status_code == 202:
+        for status in response.json()['data']:
+            assert status['catalogId'] == 'qa-catalog'
+            assert status['followUp']
+
+    response = requests.post(url.format('xyz'))
+    assert response.status_code == 404
+
+
+def test_template_version_validate_answers(client):
+    templates = client.list_template(catalogId='qa-catalog')
+    assert len(templates) > 0
+    version_url = templates[0].versionLinks.values()[0]
+    questions = requests.get(version_url).json()['questions']
+    unanswered = [q['variable'] for q in questions
+                  if q['required'] and q['default'] == '']
+    url = version_url + '/validate'
+    response = requests.post(url, json={})
+    assert response.status_code == 200
+    resp = response.json()
+    assert resp['valid'] is (len(unanswered) == 0)
+    assert len(resp['errors']) == len(unanswered)
+    for variable in unanswered:
+        assert variable + ': an answer is required' in resp['errors']
+
+    response = requests.post(url, data='[]')
+    assert response.status_code == 400
+
+
+def test_template_trust_filter(client):
+    templates = client.list_template(trust='certified')
+    assert len(templates) > 0
+    for i in range(len(templates)):
+        assert templates[i].trust == 'certified'
+    ids = [template.id for template in templates]
+    assert 'fixture-catalog:certified' in ids
+    assert 'fixture-catalog:community' not in ids
+
+    templates = client.list_template(catalogId='fixture-catalog')
+    ids = [template.id for template in templates]
+    assert 'fixture-catalog:certified' in ids
+    assert 'fixture-catalog:community' in ids
+
+    templates = client.list_template(trust='invalid')
+    assert len(templates) == 0
+
+
+def test_template_dark_icon(client):
+    url = 'http://localhost:8088/v1-catalog/templates/fixture-catalog:'
+    response = requests.get(url + 'certified?image&theme=dark')
+    assert response.status_code == 200
+    assert 'certified-dark' in response.text
+    response = requests.get(url + 'certified?image')
+    assert response.status_code == 200
+    assert 'certified-light' in response.text
+
+    # the community template has no dark icon, its light icon is served instead
+    response = requests.get(url + 'community?image&theme=dark')
+    assert response.status_code == 200
+    assert 'community-light' in response.text
+
+    links = requests.get(url + 'certified').json()['links']
+    response = requests.get(links['iconDark'])
+    assert response.status_code == 200
+    assert 'certified-dark' in response.text
+
+
+def test_admin_reclone(client):
+    url = 'http://localhost:8088/v1-catalog/admin/reclone'
+    response = requests.post(url)
+    assert response.status_code in (200, 409)
+    if response.status_code == 200:
+        resp = response.json()
+        assert len(resp['data']) > 0
+        for status in resp['data']:
+            assert len(status['sha']) == 40
+
+
+def test_template_head(client):
+    templates = client.list_template(catalogId='qa-catalog')
+    assert len(templates) > 0
+    url = 'http://localhost:8088/v1-catalog/templates/' + templates[0].id
+    response = requests.head(url)
+    assert response.status_code == 200
+    assert response.text == ''
+    etag = response.headers['ETag']
+    identity = {'Accept-Encoding': 'identity'}
+    assert requests.get(url, headers=identity).headers['ETag'] == etag
+
+    identity['If-None-Match'] = etag
+    response = requests.get(url, headers=identity)
+    assert response.status_code == 304
+
+    # a compressed response is tagged apart from the uncompressed one
+    response = requests.get(url, headers={'Accept-Encoding': 'gzip'})
+    assert response.headers['Content-Encoding'] == 'gzip'
+    assert response.headers['ETag'] != etag
+    response = requests.get(url, headers={'Accept-Encoding': 'gzip',
+                                          'If-None-Match': etag})
+    assert response.status_code == 200
+
+    response = requests.head(url + 'xyz')
+    assert response.status_code == 404
+
+
+def test_template_version_count(client):
+    templates = client.list_template(catalogId='qa-catalog')
+    assert len(templates) > 0
+    for i in range(len(templates)):
+        assert templates[i].versionCount == len(templates[i].versionLinks)
+
+
+def test_template_malformed_id(client):
+    url = 'http://localhost:8088/v1-catalog/templates/'
+    for malformed in ['qa-catalog:..', 'qa-catalog::0', 'a:b:c:d']:
+        response = requests.get(url + malformed)
+        assert response.status_code == 400
+
+    response = requests.get(url + 'qa-catalog:xyz')
+    assert response.status_code == 404
+
+
+def test_api_version_catalogs(client):
+    response = requests.get('http://localhost:8088/v1-catalog')
+    assert response.status_code == 200
+    catalogs = response.json()['catalogs']
+    assert 'qa-catalog' in [catalog['id'] for catalog in catalogs]
+    for catalog in catalogs:
+        assert 'name' in catalog
+        assert 'description' in catalog
+        assert 'logo' in catalog
+
+
+def test_template_has_questions_filter(client):
+    url = 'http://localhost:8088/v1-catalog/templates?catalogId=qa-catalog'
+    configurable = requests.get(url + '&hasQuestions=true').json()['data']
+    zero_config = requests.get(url + '&hasQuestions=false').json()['data']
+    for template in configurable:
+        assert template['hasQuestions']
+    for template in zero_config:
+        assert not template['hasQuestions']
+    all_templates = client.list_template(catalogId='qa-catalog')
+    assert len(configurable) + len(zero_config) == len(all_templates)
+
+    for value in ('maybe', '1', 'TRUE'):
+        response = requests.get(url + '&hasQuestions=' + value)
+        assert response.status_code == 400
+
+
+def test_template_sort(client):
+    url = 'http://localhost:8088/v1-catalog/templates?catalogId=qa-catalog'
+    by_weight = requests.get(url + '&sort=weight').json()['data']
+    weights = [template.get('weight', 0) for template in by_weight]
+    assert weights == sorted(weights, reverse=True)
+
+    by_name = requests.get(url + '&sort=name').json()['data']
+    names = [template['name'].lower() for template in by_name]
+    assert names == sorted(names)
+
+    response = requests.get(url + '&sort=xyz')
+    assert response.status_code == 400
+
+
+def test_service_index(client):
+    response = requests.get('http://localhost:8088/')
+    assert response.status_code == 200
+    index = response.json()
+    assert index['data'][0]['id'] == 'v1-catalog'
+    assert index['version'] is not None
+    names = [endpoint['name'] for endpoint in index['endpoints']]
+    for name in ['ListTemplates', 'LoadTemplateDetails',
+                 'LoadTemplateVersionDetails', 'RefreshCatalog']:
+        assert name in names
+
+
+def test_template_version_render(client):
+    templates = client.list_template(catalogId='qa-catalog')
+    assert len(templates) > 0
+    url = 'http://localhost:8088/v1-catalog/templates/'
+    version_id = templates[0].versionLinks.values()[0].split('/')[-1]
+    response = requests.post(url + version_id + '/render', json={})
+    assert response.status_code in (200, 400)
+    if response.status_code == 200:
+        for file_name in response.json()['files']:
+            assert 'compose' in file_name
+
+    response = requests.post(url + 'qa-catalog:xyz:0/render', json={})
+    assert response.status_code == 404
+
+
+def test_template_search(client):
+    templates = client.list_template(catalogId='qa-catalog')
+    assert len(templates) > 0
+    url = 'http://localhost:8088/v1-catalog/templates?catalogId=qa-catalog'
+    found = requests.get(url + '&search=' + templates[0].name).json()['data']
+    assert templates[0].id in [template['id'] for template in found]
+
+    found = requests.get(url + '&search=xyz-no-such-template').json()['data']
+    assert len(found) == 0
+
+
+def test_template_latest_version(client):
+    templates = client.list_template(catalogId='qa-catalog')
+    assert len(templates) > 0
+    url = 'http://localhost:8088/v1-catalog/templates/'
+    for template in templates:
+        latest = requests.get(url + template.id).json().get('latestVersion')
+        if latest is None:
+            continue
+        assert latest in template.versionLinks
+        version_url = template.versionLinks[latest]
+        assert requests.get(version_url).json()['isLatest']
+
+
+def test_readiness(client):
+    response = requests.get('http://localhost:8088/readiness')
+    assert response.status_code == 200
+    assert response.json()['ready']
+    assert response.json()['problems'] == []
+
+
+def test_template_yanked_versions(client):
+    templates = client.list_template(catalogId='qa-catalog')
+    assert len(templates) > 0
+    url = 'http://localhost:8088/v1-catalog/templates/'
+    for template in templates:
+        response = requests.get(url + template.id + '?includeYanked=true')
+        assert response.status_code == 200
+        yanked = response.json().get('yankedVersions', [])
+        for version in yanked:
+            assert version in response.json()['versionLinks']
+            assert version not in template.versionLinks
+
+    response = requests.get(url + templates[0].id + '?includeYanked=xyz')
+    assert response.status_code == 400
+
+
+def test_template_updated_within_filter(client):
+    url = 'http://localhost:8088/v1-catalog/templates?catalogId=qa-catalog'
+    templates = requests.get(url).json()['data']
+    assert len(templates) > 0
+    for template in templates:
+        assert template['updatedAt'] != ''
+
+    found = requests.get(url + '&updatedWithin=36500d').json()['data']
+    assert len(found) == len(templates)
+
+    response = requests.get(url + '&updatedWithin=xyz')
+    assert response.status_code == 400
+
+
+def test_admin_selftest(client):
+    response = requests.get('http://localhost:8088/v1-catalog/admin/selftest')
+    assert response.status_code == 200
+    report = response.json()
+    assert report['passed']
+    assert report['templateVersionId'].startswith('qa-catalog:')
+    names = [check['name'] for check in report['checks']]
+    assert names == ['template', 'config', 'version', 'compose', 'questions']
+
+
+def test_template_ordered_versions(client):
+    templates = client.list_template(catalogId='qa-catalog')
+    assert len(templates) > 0
+    url = 'http://localhost:8088/v1-catalog/templates/'
+    for template in templates:
+        response = requests.get(url + template.id).json()
+        versions = response.get('versions', [])
+        assert sorted(versions) == sorted(response['versionLinks'].keys())
+        for version in response.get('versionNames', {}):
+            assert version in versions
+
+
+def test_template_diff(client):
+    templates = client.list_template(catalogId='qa-catalog')
+    assert len(templates) > 0
+    url = 'http://localhost:8088/v1-catalog/templates/'
+    for template in templates:
+        versions = list(template.versionLinks.keys())
+        diff_url = url + template.id + '/diff?from=' + versions[0]
+        response = requests.get(diff_url + '&to=' + versions[0])
+        assert response.status_code == 200
+        assert response.json()['changedFiles'] == []
+        assert response.json()['diff'] == ''
+
+    response = requests.get(url + templates[0].id + '/diff?from=xyz&to=xyz')
+    assert response.status_code == 404
+    response = requests.get(url + templates[0].id + '/diff')
+    assert response.status_code == 400
+    response = requests.get(url + templates[0].id +
+                            '/diff?from=xyz&to=../../../../../etc')
+    assert response.status_code == 400
+
+
+def test_template_compatibility(client):
+    templates = client.list_template(catalogId='qa-catalog')
+    assert len(templates) > 0
+    url = 'http://localhost:8088/v1-catalog/templates/'
+    for template in templates:
+        response = requests.get(url + template.id + '/compatibility')
+        assert response.status_code == 200
+        compatibility = response.json()
+        assert compatibility['templateId'] == template.id
+        versions = [row['version'] for row in compatibility['versions']]
+        for version in template.versionLinks.keys():
+            assert version in versions
+
+    response = requests.get(url + 'qa-catalog:xyz/compatibility')
+    assert response.status_code == 404
+    response = requests.get(url + 'xyz/compatibility')
+    assert response.status_code == 400
+
+
+def test_template_versions_batch(client):
+    templates = client.list_template(catalogId='qa-catalog')
+    assert len(templates) > 0
+    url = 'http://localhost:8088/v1-catalog/templates/'
+    for template in templates:
+        versions = list(template.versionLinks.keys())
+        response = requests.post(url + template.id + '/versions:batch',
+                                 json=versions + ['xyz'])
+        assert response.status_code == 200
+        batch = response.json()
+        assert sorted(batch['versions'].keys()) == sorted(versions)
+        assert batch['missing'] == ['xyz']
+
+    response = requests.post(url + templates[0].id + '/versions:batch',
+                             json={'versions': []})
+    assert response.status_code == 400
+    response = requests.post(url + templates[0].id + '/versions:batch',
+                             json=['../../../../../etc'])
+    assert response.status_code == 400
+    response = requests.post(url + 'qa-catalog:xyz/versions:batch', json=[])
+    assert response.status_code == 404
+
+
+def test_template_questions_schema_filter(client):
+    url = 'http://localhost:8088/v1-catalog/templates'
+    response = requests.get(url + '?questionsSchema_lte=1')
+    assert response.status_code == 200
+    for template in response.json()['data']:
+        schemas = template.get('versionQuestionsSchemas', {})
+        for version in template['versionLinks']:
+            assert int(schemas.get(version, '1')) <= 1
+
+    response = requests.get(url + '?questionsSchema=1')
+    assert response.status_code == 200
+    response = requests.get(url + '?questionsSchema=x')
+    assert response.status_code == 400
+
+
+def test_template_default_version(client):
+    templates = client.list_template(catalogId='qa-catalog')
+    assert len(templates) > 0
+    url = 'http://localhost:8088/v1-catalog/templates/'
+    for template in templates:
+        response = requests.get(url + template.id + '/default')
+        if template.defaultVersion in template.versionLinks:
+            assert response.status_code == 200
+            assert response.json()['version'] == template.defaultVersion
+        else:
+            assert response.status_code == 404
+            assert response.json()['message'] is not None
+
+    response = requests.get(url + 'qa-catalog:xyz/default')
+    assert response.status_code == 404
+
+
+def test_template_config_file(client):
+    templates = client.list_template(catalogId='qa-catalog')
+    assert len(templates) > 0
+    url = 'http://localhost:8088/v1-catalog/templates/'
+    for template in templates:
+        response = requests.get(url + template.id + '/config.yml')
+        assert response.status_code in (200, 404)
+        if response.status_code == 200:
+            assert 'X-Catalog-Config-File' in response.headers
+            assert 'X-Catalog-Config-Inherited' not in response.headers
+
+        versionID = template.versionLinks.values()[0].split('/')[-1]
+        response = requests.get(url + versionID + '/config.yml')
+        if response.status_code == 200:
+            assert response.headers['X-Catalog-Config-Inherited'] == 'true'
+
+    response = requests.get(url + 'qa-catalog:xyz/config.yml')
+    assert response.status_code == 404
+
+
+def test_template_history(client):
+    templates = client.list_template(catalogId='qa-catalog')
+    assert len(templates) > 0
+    url = 'http://localhost:8088/v1-catalog/templates/'
+    response = requests.get(url + templates[0].id + '/history?limit=1')
+    assert response.status_code == 200
+    history = response.json()
+    assert len(history['data']) == 1
+    commit = history['data'][0]
+    assert commit['sha'] != ''
+    assert commit['author'] != ''
+    assert commit['date'] != ''
+    if 'next' in history['pagination']:
+        response = requests.get(history['pagination']['next'])
+        assert response.status_code == 200
+        assert response.json()['data'][0]['sha'] != commit['sha']
+
+    response = requests.get(url + templates[0].id + '/history?limit=0')
+    assert response.status_code == 400
+    response = requests.get(url + 'qa-catalog:xyz/history')
+    assert response.status_code == 404
+
+
+def test_template_version_default_answers(client):
+    templates = client.list_template(catalogId='qa-catalog')
+    assert len(templates) > 0
+    url = 'http://localhost:8088/v1-catalog/templates/'
+    for template in templates:
+        for version in template.versionLinks:
+            response = requests.get(url + template.id + ':' + version +
+                                    '/answers')
+            assert response.status_code == 200
+            assert response.headers['Content-Type'].startswith('text/plain')
+            for line in response.text.splitlines():
+                assert line.startswith('#') or '=' in line
+
+    response = requests.get(url + 'qa-catalog:xyz:0/answers')
+    assert response.status_code == 404
+    response = requests.get(url + 'qa-catalog:xyz/answers')
+    assert response.status_code == 400
+
+
+def test_template_version_compose_version(client):
+    templates = client.list_template(catalogId='qa-catalog')
+    assert len(templates) > 0
+    url = 'http://localhost:8088/v1-catalog/templates/'
+    for template in templates:
+        for version in template.versionLinks:
+            response = requests.get(url + template.id + ':' + version,
+                                    params={'include': 'compose'})
+            assert response.status_code == 200
+            resp = response.json()
+            if 'docker-compose.yml' not in resp['files']:
+                continue
+            assert resp['composeVersion'] != ''
+            deprecated = resp.get('deprecatedSyntax', False)
+            assert deprecated == (resp['composeVersion'] == '1')
+
+
+def test_template_screenshots(client):
+    templates = client.list_template(catalogId='qa-catalog')
+    assert len(templates) > 0
+    for template in templates:
+        screenshots = getattr(template, 'screenshots', None) or []
+        for screenshot in screenshots:
+            response = requests.get(screenshot)
+            assert response.status_code == 200
+            assert response.headers['Content-Type'].startswith('image/')
+
+    url = 'http://localhost:8088/v1-catalog/templates/'
+    response = requests.get(url + templates[0].id + '/screenshots/none.png')
+    assert response.status_code == 404
+    response = requests.get(url + templates[0].id + '/screenshots/notes.txt')
+    assert response.status_code == 404
+
+
+def test_template_version_size(client):
+    templates = client.list_template(catalogId='qa-catalog')
+    assert len(templates) > 0
+    url = 'http://localhost:8088/v1-catalog/templates/'
+    for template in templates:
+        for version in template.versionLinks:
+            response = requests.get(url + template.id + ':' + version,
+                                    params={'include': 'compose'})
+            assert response.status_code == 200
+            resp = response.json()
+            assert resp['fileCount'] >= len(resp['files'])
+            assert resp['sizeBytes'] >= sum(len(content) for content
+                                            in resp['files'].values())
+
+
+def test_openapi_document(client):
+    url = 'http://localhost:8088/v1-catalog/openapi.json'
+    response = requests.get(url)
+    assert response.status_code == 200
+    document = response.json()
+    assert document['openapi'].startswith('3.')
+
+    index = requests.get('http://localhost:8088/').json()
+    for endpoint in index['endpoints']:
+        path = endpoint['path'].split('?')[0]
+        operations = document['paths'][path]
+        operation = operations[endpoint['method'].lower()]
+        assert operation['operationId'] == endpoint['name']
+
+    schemas = document['components']['schemas']
+    assert 'versionLinks' in schemas['template']['properties']
+    assert 'variable' in schemas['question']['properties']
+    # the fields the api schemas leave out are left out of the document
+    assert 'questions' not in schemas['template']['properties']
+    assert 'questions' in schemas['templateVersion']['properties']
+    detail = document['paths']['/v1-catalog/templates/'
+                               '{catalog_template_version_Id}']['get']
+    schema = detail['responses']['200']['content']['application/json']
+    refs = [option['$ref'] for option in schema['schema']['oneOf']]
+    assert refs == ['#/components/schemas/template',
+                    '#/components/schemas/templateVersion']
+    version = document['paths']['/v1-catalog/templateversions/'
+                                '{catalog_template_version_Id}']['get']
+    schema = version['responses']['200']['content']['application/json']
+    assert schema['schema']['$ref'] == \
+        '#/components/schemas/templateVersion'
+
+
+def test_template_version_raw_compose(client):
+    templates = client.list_template(catalogId='qa-catalog')
+    assert len(templates) > 0
+    url = 'http://localhost:8088/v1-catalog/templates/'
+    for template in templates:
+        for version in template.versionLinks:
+            response = requests.get(url + template.id + ':' + version,
+                                    params={'include': 'compose'})
+            assert response.status_code == 200
+            raw = requests.get(url + template.id + ':' + version,
+                               params={'raw': 'true', 'include': 'compose'})
+            assert raw.status_code == 200
+            # no registry rewrites are configured for the tests
+            assert raw.json()['files'] == response.json()['files']
+
+
+def test_admin_question_usages(client):
+    url = 'http://localhost:8088/v1-catalog/admin/questions'
+    response = requests.get(url)
+    assert response.status_code == 200
+    usages = response.json()['data']
+    variables = [usage['variable'] for usage in usages]
+    assert variables == sorted(set(variables))
+    for usage in usages:
+        assert len(usage['types']) > 0
+        assert len(usage['templates']) > 0
+
+    templates = client.list_template(catalogId='qa-catalog')
+    for template in templates:
+        if not template.hasQuestions:
+            continue
+        metadata = requests.get('http://localhost:8088/v1-catalog/templates/' +
+                                template.id).json()
+        default = metadata['versionLinks'].get(metadata['defaultVersion'])
+        if default is None:
+            continue
+        questions = requests.get(default + '?include=questions').json()
+        for question in questions['questions']:
+            usage = usages[variables.index(question['variable'])]
+            assert template.id in usage['templates']
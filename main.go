@@ -2,7 +2,11 @@ package main
 
 import (
 	"fmt"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 
 	log "github.com/Sirupsen/logrus"
 	"github.com/rancher/rancher-catalog-service/manager"
@@ -10,13 +14,51 @@ import (
 )
 
 func main() {
-	log.Infof("Starting Rancher Catalog service")
+	log.Infof("Starting Rancher Catalog service %s", manager.Version)
 	router := service.NewRouter()
-	handler := service.MuxWrapper{false, router}
+	handler := service.MuxWrapper{IsReady: false, Router: router}
 
 	manager.GetCommandLine()
 
 	go manager.Init()
 	manager.WatchSignals()
+	if *manager.ListenSocket != "" {
+		if err := serveUnixSocket(*manager.ListenSocket, &handler); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
 	log.Fatal(http.ListenAndServe(fmt.Sprintf(":%d", *manager.Port), &handler))
 }
+
+//serveUnixSocket serves on a Unix domain socket created at the given path, the socket is removed on shutdown
+func serveUnixSocket(socketPath string, handler http.Handler) error {
+	//a socket left behind by an instance that was killed would fail the listen
+	if err := os.Remove(socketPath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		return err
+	}
+
+	shutdown := make(chan struct{})
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		s := <-c
+		log.Infof("Received %v signal, removing the socket %s", s, socketPath)
+		close(shutdown)
+		//closing the listener removes the socket file
+		listener.Close()
+	}()
+
+	log.Infof("Listening on the Unix socket %s", socketPath)
+	err = http.Serve(listener, handler)
+	select {
+	case <-shutdown:
+		return nil
+	default:
+		return err
+	}
+}
@@ -0,0 +1,52 @@
+package manager
+
+import (
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+//resolveAlias returns the id of the template the given template id is an alias of, or the id itself;
+//a template named like an alias of another one takes precedence over the alias
+func (cat *Catalog) resolveAlias(templateID string) string {
+	if _, ok := cat.metadata[cat.CatalogID+"/"+templateID]; ok {
+		return templateID
+	}
+	if templatePath, ok := cat.aliases[cat.CatalogID+"/"+templateID]; ok {
+		return strings.TrimPrefix(templatePath, cat.CatalogID+"/")
+	}
+	if templatePath := cat.foldedPaths[strings.ToLower(cat.CatalogID+"/"+templateID)]; templatePath != "" {
+		return strings.TrimPrefix(templatePath, cat.CatalogID+"/")
+	}
+	return templateID
+}
+
+//foldPath makes the template path, or an alias of the template, found whatever its case with
+//-caseInsensitiveTemplates; paths differing only by case are left to exact lookups, with a warning
+func (cat *Catalog) foldPath(lookupPath string, templatePath string) {
+	if !*caseInsensitiveTemplates {
+		return
+	}
+	key := strings.ToLower(lookupPath)
+	folded, ok := cat.foldedPaths[key]
+	if !ok {
+		cat.foldedPaths[key] = templatePath
+		return
+	}
+	if folded == "" || folded == templatePath {
+		return
+	}
+	log.Warnf("The templates %s and %s of catalog %s differ only by case, they are found by their exact id only", folded, templatePath, cat.CatalogID)
+	cat.addDiagnostic(templatePath, "The id %s differs only by case from the template %s, it is found by its exact case only", lookupPath, folded)
+	cat.addDiagnostic(folded, "The id %s differs only by case from the template %s, it is found by its exact case only", lookupPath, templatePath)
+	cat.foldedPaths[key] = ""
+}
+
+//ResolveTemplateAlias returns the canonical id of a template that may be referred to by one of its aliases
+func ResolveTemplateAlias(catalogID string, templateID string) string {
+	cat, ok := CatalogsCollection[catalogID]
+	if !ok {
+		return templateID
+	}
+	return cat.resolveAlias(templateID)
+}
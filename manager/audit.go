@@ -0,0 +1,94 @@
+package manager
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+
+	"github.com/rancher/rancher-catalog-service/model"
+)
+
+//lastCommitFormat separates the commit fields with NUL bytes as none of them can contain one
+const lastCommitFormat string = "--format=%H%x00%an%x00%ae%x00%aI%x00%B"
+
+//GetLastCommit returns the last commit that modified the folder of the given template,
+//or of the template version if versionID is not empty
+func GetLastCommit(catalogID string, templateID string, versionID string) (model.TemplateCommit, bool, error) {
+	commit := model.TemplateCommit{}
+	cat, relativePath, ok := templateRepoPath(catalogID, templateID, versionID)
+	if !ok {
+		return commit, false, nil
+	}
+
+	e := exec.Command("git", "-C", cat.catalogRoot, "log", "-1", lastCommitFormat, "--", relativePath)
+	out, err := commandOutput(e)
+	if err != nil {
+		return commit, true, fmt.Errorf("cannot read git log of %s, error: %v", relativePath, err)
+	}
+
+	fields := strings.SplitN(string(out), "\x00", 5)
+	if len(fields) != 5 {
+		return commit, false, nil
+	}
+	commit.SHA = fields[0]
+	commit.Author = fields[1]
+	commit.AuthorEmail = fields[2]
+	commit.Date = fields[3]
+	commit.Message = strings.TrimSpace(fields[4])
+	return commit, true, nil
+}
+
+//GetHistory returns the commits that modified the folder of the given template, or of the template version if
+//versionID is not empty, newest first, skipping the first skip commits and returning at most limit of them;
+//it also tells if there are more commits past the ones returned
+func GetHistory(catalogID string, templateID string, versionID string, skip int, limit int) ([]model.TemplateCommit, bool, bool, error) {
+	cat, relativePath, ok := templateRepoPath(catalogID, templateID, versionID)
+	if !ok {
+		return nil, false, false, nil
+	}
+
+	//read one commit past the limit to tell if there are more
+	e := exec.Command("git", "-C", cat.catalogRoot, "log", fmt.Sprintf("--skip=%d", skip), fmt.Sprintf("--max-count=%d", limit+1),
+		lastCommitFormat+"%x00", "--", relativePath)
+	out, err := commandOutput(e)
+	if err != nil {
+		return nil, true, false, fmt.Errorf("cannot read git log of %s, error: %v", relativePath, err)
+	}
+
+	commits := []model.TemplateCommit{}
+	fields := strings.Split(string(out), "\x00")
+	for i := 0; i+5 <= len(fields); i += 5 {
+		commits = append(commits, model.TemplateCommit{
+			SHA:         strings.TrimSpace(fields[i]),
+			Author:      fields[i+1],
+			AuthorEmail: fields[i+2],
+			Date:        fields[i+3],
+			Message:     strings.TrimSpace(fields[i+4]),
+		})
+	}
+	if len(commits) > limit {
+		return commits[:limit], true, true, nil
+	}
+	return commits, true, false, nil
+}
+
+//templateRepoPath returns the catalog of the given template and the path of the folder of the template, or of
+//the template version if versionID is not empty, in its repo; it returns false if there is no such folder
+func templateRepoPath(catalogID string, templateID string, versionID string) (*Catalog, string, bool) {
+	cat, ok := CatalogsCollection[catalogID]
+	if !ok {
+		return nil, "", false
+	}
+	if _, ok := cat.metadata[catalogID+"/"+templateID]; !ok {
+		return nil, "", false
+	}
+
+	prefix, templateName := cat.templateFolder(templateID)
+	relativePath := path.Join(prefix, templateName, versionID)
+	if _, err := os.Stat(path.Join(cat.catalogRoot, relativePath)); err != nil {
+		return nil, "", false
+	}
+	return cat, relativePath, true
+}
@@ -1,23 +1,37 @@
 package manager
 
 import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"os/exec"
 	"path"
 	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
+	"unicode/utf16"
+	"unicode/utf8"
 
 	log "github.com/Sirupsen/logrus"
+	"github.com/blang/semver"
 	"github.com/rancher/go-rancher/client"
 	"github.com/rancher/rancher-catalog-service/model"
 	"github.com/rancher/rancher-compose/lookup"
 	"gopkg.in/yaml.v2"
 )
 
+//installNotesFile holds the post install notes of a template version
+const installNotesFile string = "notes.txt"
+
+//latestPointer is the symlink to, or the file naming, the version folder of the latest version of a template
+const latestPointer string = "latest"
+
 var (
 	metadataFolder = regexp.MustCompile(`^DATA/[^/]+/((\w+)+-templates|templates)/[^/]+$`)
 )
@@ -31,17 +45,48 @@ type CatalogCollection struct {
 //Catalog defines the properties of a template Catalog
 type Catalog struct {
 	client.Resource
-	CatalogID         string `json:"id"`
-	Description       string `json:"description"`
-	CatalogLink       string `json:"catalogLink"`
-	URL               string `json:"uri"`
-	State             string `json:"state"`
-	LastUpdated       string `json:"lastUpdated"`
-	Message           string `json:"message"`
-	catalogRoot       string
-	refreshReqChannel *chan int
-	metadata          map[string]model.Template
-	URLBranch         string `json:"branch"`
+	CatalogID   string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Logo        string `json:"logo"`
+	CatalogLink string `json:"catalogLink"`
+	URL         string `json:"uri"`
+	State       string `json:"state"`
+	LastUpdated string `json:"lastUpdated"`
+	Message     string `json:"message"`
+	catalogRoot string
+	metadata    map[string]model.Template
+	diagnostics map[string][]string
+	//embedded is set for the catalog baked into the binary, which is never refreshed
+	embedded bool
+	//walkedCommit is the HEAD commit of an external checkout when it was last walked
+	walkedCommit string
+	//verifiedCommit is the last commit checked out whose signature passed -requireSignedCommits
+	verifiedCommit string
+	//syncedListing is the fingerprint of the objects of a catalog synced from object storage when it was last synced
+	syncedListing string
+	//needsClone is set while the catalog could not be cloned and is served from the copy on disk, if any
+	needsClone bool
+	//helmVersions holds the chart versions read from a Helm index.yaml by template path and version
+	helmVersions map[string]map[string]model.Template
+	//aliases maps the paths of the former names of templates to the template path
+	aliases map[string]string
+	//templateDirs maps the template paths to the templates folder holding the template
+	templateDirs map[string]string
+	//foldedPaths maps the lower cased template paths and aliases to the template path with
+	//-caseInsensitiveTemplates, to an empty path for the ones of several templates
+	foldedPaths map[string]string
+	//refreshDeadline is the time the refresh in progress is aborted at, zero if it has no deadline
+	refreshDeadline time.Time
+	//zipBundles holds the zip bundles extracted to a catalog read from a directory of zip bundles, by file name
+	zipBundles map[string]zipBundle
+	//parsed and parseFailed count the template configs and versions read by the last walk and the ones that
+	//failed to parse
+	parsed      int
+	parseFailed int
+	//worktreeOf is the id of the catalog whose clone the branch of this catalog is checked out as a worktree of
+	worktreeOf string
+	URLBranch  string `json:"branch"`
 }
 
 func (cat *Catalog) getID() string {
@@ -49,11 +94,31 @@ func (cat *Catalog) getID() string {
 }
 
 func (cat *Catalog) readCatalog() error {
+	if cat.embedded {
+		return cat.readEmbeddedCatalog()
+	}
+	if cat.fromObjectStorage() {
+		return cat.readObjectStorage()
+	}
+	if cat.fromZipBundles() {
+		return cat.readZipBundles()
+	}
+	if *externalCheckout {
+		return cat.readExternalCheckout()
+	}
+	if cat.worktreeOf != "" {
+		return cat.readWorktree()
+	}
 	_, err := os.Stat(CatalogRootDir + cat.CatalogID)
 	if !os.IsNotExist(err) || err == nil {
+		if !cat.gitRepoHealthy() {
+			//an interrupted clone leaves a repo that can neither be pulled nor verified
+			log.Warnf("Catalog %v has a broken git repo, cloning it again", cat.CatalogID)
+			return cat.cloneCatalog()
+		}
 		//catalog exists, check if url matches
 		e := exec.Command("git", "-C", cat.catalogRoot, "config", "--get", "remote.origin.url")
-		out, err := e.Output()
+		out, err := commandOutput(e)
 		if err != nil {
 			log.Errorf("Cannot verify Git repo for Catalog %v, error: %v ", cat.CatalogID, err)
 			return err
@@ -62,76 +127,226 @@ func (cat *Catalog) readCatalog() error {
 		repoURL = strings.TrimSpace(repoURL)
 		if repoURL == cat.URL {
 			log.Debugf("Catalog %v already exists with same repo url, pulling updates", cat.CatalogID)
-			cat.metadata = make(map[string]model.Template)
-			//walk the catalog and read the metadata to the cache
-			filepath.Walk(cat.catalogRoot, cat.walkCatalog)
-			if ValidationMode {
-				log.Infof("Catalog loaded without errors")
-				os.Exit(0)
+			if err := cat.checkoutInitialTag(); err != nil {
+				return err
 			}
-		} else {
-			//remove the existing repo
-			err := os.RemoveAll(CatalogRootDir + cat.CatalogID)
-			if err != nil {
-				log.Errorf("Cannot remove the existing catalog folder %v, error: %v", cat.CatalogID, err)
+			if err := cat.verifyExistingCommit(); err != nil {
 				return err
 			}
+			//walk the catalog and read the metadata to the cache
+			return cat.loadInitialMetadata()
+		} else {
+			//the existing repo is replaced once the clone succeeds
 			return cat.cloneCatalog()
 		}
 	} else {
 		log.Debugf("Catalog %v does not exist, proceeding to clone the repo : ", cat.CatalogID)
 		return cat.cloneCatalog()
 	}
-	return nil
 }
 
 func (cat *Catalog) cloneCatalog() error {
-	var e *exec.Cmd
+	if cat.worktreeOf != "" {
+		return cat.addWorktree()
+	}
 	//git clone the repo
 	// git clone -b mybranch --single-branch git://sub.domain.com/repo.git
+	args := []string{"clone", "--recursive"}
 
 	if cat.URLBranch == "master" {
 		log.Infof("Cloning the catalog from git URL %s", cat.URL)
-		e = exec.Command("git", "clone", "--recursive", cat.URL, cat.catalogRoot)
 	} else {
 		log.Infof("Branch : %s", cat.URLBranch)
 		log.Infof("Cloning the catalog from git URL branch %s to directory %s", cat.URLBranch, cat.catalogRoot)
-		e = exec.Command("git", "clone", "--recursive", "-b", cat.URLBranch, cat.URL, cat.catalogRoot)
+		args = append(args, "-b", cat.URLBranch)
 	}
 
+	if referenceRepo := validReferenceRepo(); referenceRepo != "" {
+		log.Infof("Sharing objects with the reference repo %s", referenceRepo)
+		args = append(args, "--reference", referenceRepo)
+	}
+
+	//clone next to the catalog root, so that an existing copy keeps being served if the clone fails
+	stage := CatalogRootDir + "." + cat.CatalogID + "-clone"
+	os.RemoveAll(stage)
+
+	args = append(args, cat.URL, stage)
+	e := exec.Command("git", args...)
 	e.Stdout = os.Stdout
 	e.Stderr = os.Stderr
-	err := e.Run()
+	err := runCommand(e)
+	if err == nil && *requireSignedCommits {
+		err = verifyCommit(stage)
+	}
+	if err == nil {
+		err = replaceCatalogRoot(stage, cat.catalogRoot)
+	}
+	if err == nil && *requireSignedCommits {
+		cat.verifiedCommit, _ = cat.headCommit()
+	}
 	if err != nil {
+		os.RemoveAll(stage)
 		errorStr := "Failed to clone the catalog from git err: " + err.Error()
 		log.Error(errorStr)
-		cat.State = "error"
-		cat.Message = errorStr
+		//the background poll retries the clone instead of pulling
+		cat.needsClone = true
+		if cat.serveStandby() {
+			cat.State = "degraded"
+			cat.Message = errorStr + ", serving the last known copy of the catalog"
+		} else {
+			cat.State = "error"
+			cat.Message = errorStr
+		}
 		return err
 	}
+	cat.needsClone = false
 
 	log.Info("Cloning completed")
 
-	cat.metadata = make(map[string]model.Template)
+	if err := cat.checkoutInitialTag(); err != nil {
+		return err
+	}
 	//walk the catalog and read the metadata to the cache
-	filepath.Walk(cat.catalogRoot, cat.walkCatalog)
-	if ValidationMode {
-		log.Infof("Catalog loaded without errors")
-		os.Exit(0)
+	if err := cat.loadInitialMetadata(); err != nil {
+		return err
 	}
 	cat.LastUpdated = time.Now().Format(time.RFC3339)
 	cat.State = "active"
+	cat.Message = ""
 	return nil
 }
 
+//replaceCatalogRoot moves a fresh clone into place of the catalog root, removing any previous copy
+func replaceCatalogRoot(stage string, catalogRoot string) error {
+	if err := removeCatalogRoot(catalogRoot); err != nil {
+		return err
+	}
+	return os.Rename(stage, catalogRoot)
+}
+
+//serveStandby loads the copy of the catalog left on disk by a previous run, if any, so that it can be
+//served while the catalog cannot be cloned
+func (cat *Catalog) serveStandby() bool {
+	if _, err := os.Stat(cat.catalogRoot); err != nil {
+		return false
+	}
+	log.Warnf("Serving the last known copy of catalog %v until it can be cloned", cat.CatalogID)
+	cat.loadMetadata()
+	forgetQuestions(cat.CatalogID)
+	return true
+}
+
+//validReferenceRepo returns the configured reference repo if it is a usable git repo
+func validReferenceRepo() string {
+	if *referenceRepo == "" {
+		return ""
+	}
+	e := exec.Command("git", "-C", *referenceRepo, "rev-parse", "--git-dir")
+	if err := runCommand(e); err != nil {
+		log.Warnf("Reference repo %s is not a valid git repo, proceeding with a full clone, error: %v", *referenceRepo, err)
+		return ""
+	}
+	return *referenceRepo
+}
+
+//gitRepoHealthy checks that the catalog folder holds a git repo with a checked out commit,
+//the git dir is given explicitly so that git does not pick up a repo from a parent folder
+func (cat *Catalog) gitRepoHealthy() bool {
+	e := exec.Command("git", "--git-dir", path.Join(cat.catalogRoot, ".git"), "rev-parse", "--verify", "--quiet", "HEAD^{commit}")
+	if err := runCommand(e); err != nil {
+		log.Debugf("Git repo of catalog %v failed verification, error: %v", cat.CatalogID, err)
+		return false
+	}
+	return true
+}
+
+//loadMetadata walks the catalog and reads the template metadata to the cache, it returns an error
+//if the walk was aborted at the refresh deadline
+func (cat *Catalog) loadMetadata() error {
+	cat.metadata = make(map[string]model.Template)
+	cat.diagnostics = make(map[string][]string)
+	cat.aliases = make(map[string]string)
+	cat.templateDirs = make(map[string]string)
+	cat.foldedPaths = make(map[string]string)
+	cat.parsed, cat.parseFailed = 0, 0
+	var err error
+	if len(templatesDirList) > 0 {
+		err = cat.walkTemplatesDirs()
+	} else {
+		//the trailing separator makes the walk descend into a catalog root that is a symlink to a snapshot
+		err = filepath.Walk(cat.catalogRoot+"/", cat.walkCatalog)
+	}
+	if err == errTemplateLimit {
+		log.Warnf("Stopped loading the templates of catalog %s at the maximum of %d templates", cat.CatalogID, *maxTemplates)
+	} else if err != nil {
+		return err
+	}
+	cat.readCommitTimes()
+	cat.readHelmIndex()
+	cat.checkDependencies()
+	cat.readCatalogInfo()
+	return cat.checkParseFailures()
+}
+
+//loadInitialMetadata walks the catalog on its first load, the catalog being degraded if too many templates failed
+//to parse, the templates read being served, and in error if the walk failed
+func (cat *Catalog) loadInitialMetadata() error {
+	err := cat.loadMetadata()
+	//the walk replaced the templates served, as on a reload of the catalogs
+	forgetQuestions(cat.CatalogID)
+	if err == errParseFailures {
+		cat.State = "degraded"
+		cat.Message = cat.parseFailureProblem()
+	} else if err != nil {
+		errorStr := "Failed to walk the catalog err: " + err.Error()
+		log.Error(errorStr)
+		cat.State = "error"
+		cat.Message = errorStr
+	}
+	if ValidationMode {
+		if err != nil {
+			log.Fatalf("Catalog failed to load: %s", cat.Message)
+		}
+		log.Infof("Catalog loaded without errors")
+		os.Exit(0)
+	}
+	return err
+}
+
+//adoptMetadata serves the templates read by a walk of a staged copy of the catalog
+func (cat *Catalog) adoptMetadata(staged *Catalog) {
+	cat.metadata = staged.metadata
+	cat.diagnostics = staged.diagnostics
+	cat.helmVersions = staged.helmVersions
+	cat.aliases = staged.aliases
+	cat.templateDirs = staged.templateDirs
+	cat.foldedPaths = staged.foldedPaths
+	forgetQuestions(cat.CatalogID)
+	cat.Name = staged.Name
+	cat.Description = staged.Description
+	cat.Logo = staged.Logo
+}
+
+//addDiagnostic records a problem found while loading the template at the given path
+func (cat *Catalog) addDiagnostic(templatePath string, format string, args ...interface{}) {
+	cat.diagnostics[templatePath] = append(cat.diagnostics[templatePath], fmt.Sprintf(format, args...))
+}
+
 func (cat *Catalog) walkCatalog(filePath string, f os.FileInfo, err error) error {
 	//log.Debugf("Reading folder for template:%s %v", filePath, f)
+	if cat.deadlineExceeded() {
+		return errRefreshTimeout
+	}
+
+	//match against forward slash separated paths so that the walk also works with OS specific separators
+	slashPath := strings.TrimSuffix(filepath.ToSlash(filePath), "/")
 
-	if f != nil && f.IsDir() && metadataFolder.MatchString(filePath) {
+	templatesDir, isTemplate := cat.templatesDirOf(slashPath)
+	if f != nil && f.IsDir() && isTemplate {
 
 		//matches ./DATA/catalogID/templates/ElasticSearch or 	./DATA/catalogID/k8s-templates/ElasticSearch
 		// get the prefix like 'k8s' if any
-		prefix := metadataFolder.ReplaceAllString(filePath, "$2")
+		prefix := templatesDirPrefix(templatesDir)
 		prefixWithSeparator := prefix
 		if prefix != "" {
 			prefixWithSeparator = prefix + "*"
@@ -146,46 +361,142 @@ func (cat *Catalog) walkCatalog(filePath string, f os.FileInfo, err error) error
 			Path:         cat.CatalogID + "/" + prefixWithSeparator + f.Name(), //catalogRoot + prefix + f.Name()
 			TemplateBase: prefix,
 		}
+		if servedDir, ok := cat.templateDirs[newTemplate.Path]; ok {
+			log.Warnf("Skipping template %s of %s, the template of %s has the same id", f.Name(), templatesDir, servedDir)
+			cat.addDiagnostic(newTemplate.Path, "Skipping the template of %s, the template of %s has the same id", templatesDir, servedDir)
+			return filepath.SkipDir
+		}
 
 		//read the root level config.yml
-		readTemplateConfig(filePath, &newTemplate)
+		err := readTemplateConfig(filePath, &newTemplate)
+		if err != nil {
+			cat.addDiagnostic(newTemplate.Path, "Error reading template config: %v", err)
+		}
+		cat.countParse(err)
+		if !categoryAllowed(newTemplate.Category) {
+			log.Debugf("Skipping template %s, its category %q is not allowed", newTemplate.Path, newTemplate.Category)
+			return filepath.SkipDir
+		}
+		if !environmentVisible(newTemplate.Environments) {
+			log.Debugf("Skipping template %s, it is not visible in the environment %s", newTemplate.Path, *environment)
+			return filepath.SkipDir
+		}
+		//read the root level questions inherited by versions that have none
+		if err := readTemplateQuestions(filePath, &newTemplate); err != nil {
+			cat.addDiagnostic(newTemplate.Path, "Error reading template questions: %v", err)
+		}
 
 		//list the folders under the root level
 		newTemplate.VersionLinks = make(map[string]string)
 		newTemplate.CatalogID = cat.CatalogID
 		newTemplate.TemplateVersionRancherVersion = make(map[string]string)
 		newTemplate.TemplateVersionRancherVersionGte = make(map[string]string)
+		newTemplate.VersionStages = make(map[string]string)
+		newTemplate.VersionQuestionsSchemas = make(map[string]string)
 		dirList, err := ioutil.ReadDir(filePath)
 		if err != nil {
 			log.Errorf("Error reading directories at path: %s, error: %v", f.Name(), err)
+			cat.addDiagnostic(newTemplate.Path, "Error reading template directory: %v", err)
 		} else {
+			var iconFiles []string
+			//versionQuestions tells for every version whether deploying it asks any question
+			versionQuestions := make(map[string]bool)
+			//folderVersions maps the version folders to their version, for the latest pointer to name a folder
+			folderVersions := make(map[string]string)
+			latestFolder := ""
+			//versions.yml, if any, lists the version folders in the order they are shown
+			versionEntries, hasVersionsFile, err := readVersionsFile(filePath)
+			if err != nil {
+				//a malformed versions.yml would leave the template without versions, the folders are listed instead
+				log.Warnf("Error reading the %s of template %s, listing its version folders instead: %v", versionsFile, newTemplate.Path, err)
+				cat.addDiagnostic(newTemplate.Path, "Error reading %s, listing the version folders instead: %v", versionsFile, err)
+				versionEntries, hasVersionsFile = nil, false
+			}
+			listedFolders := make(map[string]bool)
+			for _, entry := range versionEntries {
+				listedFolders[entry.Folder] = true
+			}
+			var versionFolders []string
 			for _, subfile := range dirList {
-				if subfile.IsDir() {
+				if isScreenshotEntry(subfile) {
+					//the screenshots are listed once the version folders are read
+				} else if subfile.IsDir() && hasVersionsFile && !listedFolders[subfile.Name()] {
+					log.Debugf("Skipping the template version: %s, it is not listed in %s", path.Join(f.Name(), subfile.Name()), versionsFile)
+				} else if subfile.IsDir() {
+					if missing := missingRequiredFiles(newTemplate.Category, path.Join(filePath, subfile.Name())); len(missing) > 0 {
+						log.Warnf("The template version %s is missing the files %s required of %s templates", path.Join(f.Name(), subfile.Name()), strings.Join(missing, ", "), newTemplate.Category)
+						cat.addDiagnostic(newTemplate.Path, "The template version %s is missing the files %s required of %s templates", subfile.Name(), strings.Join(missing, ", "), newTemplate.Category)
+					}
 					//read the subversion config.yml file into a template
 					subTemplate := model.Template{}
 					err := readRancherCompose(path.Join(filePath, subfile.Name()), &subTemplate)
+					cat.countParse(err)
 					if err == nil {
+						inheritVersionConstraints(&subTemplate, &newTemplate)
 						newTemplate.VersionLinks[subTemplate.Version] = newTemplate.Id + ":" + subfile.Name()
 						newTemplate.TemplateVersionRancherVersion[subTemplate.Version] = subTemplate.MinimumRancherVersion
 						newTemplate.TemplateVersionRancherVersionGte[subTemplate.Version] = subTemplate.MaximumRancherVersion
+						newTemplate.VersionStages[subTemplate.Version] = subTemplate.Stage
+						if subTemplate.QuestionsSchema != "" {
+							newTemplate.VersionQuestionsSchemas[subTemplate.Version] = subTemplate.QuestionsSchema
+						}
+						if subTemplate.Yanked {
+							newTemplate.YankedVersions = append(newTemplate.YankedVersions, subTemplate.Version)
+						}
+						//a version asks its own questions or else those of the template, followed by the global questions,
+						//as ReadTemplateVersion serves them
+						questions := subTemplate.Questions
+						if len(questions) == 0 {
+							questions = newTemplate.Questions
+						}
+						versionQuestions[subTemplate.Version] = len(model.MergeQuestions(questions, globalQuestions)) > 0
+						folderVersions[subfile.Name()] = subTemplate.Version
+						versionFolders = append(versionFolders, subfile.Name())
 					} else {
 						subfilePath := path.Join(f.Name(), subfile.Name())
 						if ValidationMode {
 							log.Fatalf("Error processing the template version: %s, error: %v", subfilePath, err)
 						}
 						log.Infof("Skipping the template version: %s, error: %v", subfilePath, err)
+						cat.addDiagnostic(newTemplate.Path, "Skipping the template version: %s, error: %v", subfile.Name(), err)
 					}
+				} else if subfile.Name() == latestPointer {
+					latestFolder = readLatestPointer(path.Join(filePath, latestPointer))
 				} else if strings.HasPrefix(subfile.Name(), "catalogIcon") {
-					newTemplate.IconLink = newTemplate.Id + "?image"
-					PathToImage[newTemplate.Path] = subfile.Name()
+					iconFiles = append(iconFiles, subfile.Name())
 				} else if strings.HasPrefix(strings.ToLower(subfile.Name()), "readme") {
 					newTemplate.ReadmeLink = newTemplate.Id + "?readme"
-					PathToReadme[newTemplate.Path] = subfile.Name()
+					PathToReadme.set(newTemplate.Path, subfile.Name())
+				}
+			}
+			setTemplateIcons(&newTemplate, iconFiles)
+			newTemplate.Screenshots = screenshotLinks(newTemplate.Id, filePath)
+			cat.orderVersions(&newTemplate, versionEntries, hasVersionsFile, versionFolders, folderVersions)
+			newTemplate.HasQuestions = defaultVersionHasQuestions(&newTemplate, versionQuestions)
+			if latestFolder != "" && newTemplate.LatestVersion == "" {
+				if version, ok := folderVersions[latestFolder]; ok {
+					newTemplate.LatestVersion = version
+				} else {
+					cat.addDiagnostic(newTemplate.Path, "The latest pointer names the missing version folder: %s", latestFolder)
 				}
 			}
 		}
 
+		if err := transformTemplate(&newTemplate); err != nil {
+			log.Warnf("Skipping template %s, %v", newTemplate.Path, err)
+			cat.addDiagnostic(newTemplate.Path, "Skipping the template, %v", err)
+			return filepath.SkipDir
+		}
+		if err := cat.checkTemplateLimit(); err != nil {
+			return err
+		}
 		cat.metadata[newTemplate.Path] = newTemplate
+		cat.templateDirs[newTemplate.Path] = templatesDir
+		cat.foldPath(newTemplate.Path, newTemplate.Path)
+		for _, alias := range newTemplate.Aliases {
+			cat.aliases[cat.CatalogID+"/"+prefixWithSeparator+alias] = newTemplate.Path
+			cat.foldPath(cat.CatalogID+"/"+prefixWithSeparator+alias, newTemplate.Path)
+		}
 	}
 
 	return nil
@@ -194,102 +505,385 @@ func (cat *Catalog) walkCatalog(filePath string, f os.FileInfo, err error) error
 func (cat *Catalog) pullCatalog() error {
 	log.Debugf("Pulling the catalog %s from the repo to sync any new changes to %s", cat.CatalogID, cat.catalogRoot)
 
+	var err error
+	if *catalogTagPattern != "" {
+		err = cat.checkoutLatestTag()
+	} else if *resetToRemote {
+		err = cat.resetBranch()
+	} else {
+		err = cat.pullBranch()
+	}
+	if err != nil {
+		return err
+	}
+	if *requireSignedCommits {
+		if err := cat.verifyPulledCommit(); err != nil {
+			return err
+		}
+	}
+
+	log.Debugf("Update submodules of the catalog %s from the repo to sync any new changes to %s", cat.CatalogID, cat.catalogRoot)
+
+	var e *exec.Cmd
+	if *remoteSubmodule {
+		e = exec.Command("git", "-C", cat.catalogRoot, "submodule", "update", "--init", "--recursive", "--remote")
+	} else {
+		e = exec.Command("git", "-C", cat.catalogRoot, "submodule", "update", "--init", "--recursive")
+	}
+
+	err = cat.runGit(e)
+	if err != nil {
+		log.Errorf("Failed to update submodules of the catalog from git repo %s, error: %v", cat.URL, err.Error())
+		return err
+	}
+	cat.LastUpdated = time.Now().Format(time.RFC3339)
+	cat.State = "active"
+	cat.Message = ""
+	return nil
+}
+
+//pullBranch checks out the branch of the catalog and pulls its latest commits
+func (cat *Catalog) pullBranch() error {
 	// git --git-dir=./DATA/value/.git/ --work-tree=./DATA/value/ checkout new_branch
 	gitCheckoutCmd := exec.Command("git", "--git-dir="+cat.catalogRoot+"/.git", "--work-tree="+cat.catalogRoot, "checkout", cat.URLBranch)
 
-	out, gitCheckoutErr := gitCheckoutCmd.Output()
+	out, gitCheckoutErr := cat.gitOutput(gitCheckoutCmd)
 	if gitCheckoutErr != nil {
 		errorStr := "Git checkout failure from git err: " + gitCheckoutErr.Error()
 		log.Error(errorStr)
 	}
 	log.Debugf("Branch to be worked on : %s\n", out)
 
-	var e *exec.Cmd
-	e = exec.Command("git", "-C", cat.catalogRoot, "pull", "-r", "origin", cat.URLBranch)
+	e := exec.Command("git", "-C", cat.catalogRoot, "pull", "-r", "origin", cat.URLBranch)
 
-	err := e.Run()
+	err := cat.runGit(e)
 	if err != nil {
 		log.Errorf("Failed to pull the catalog from git repo %s, error: %v", cat.URL, err.Error())
 		return err
 	}
+	return nil
+}
 
-	log.Debugf("Update submodules of the catalog %s from the repo to sync any new changes to %s", cat.CatalogID, cat.catalogRoot)
+//resetBranch fetches the branch of the catalog and resets the working tree to it, discarding any local
+//commit or change, so that a force-pushed branch is followed instead of merged
+func (cat *Catalog) resetBranch() error {
+	e := exec.Command("git", "-C", cat.catalogRoot, "fetch", "origin", cat.URLBranch)
+	if err := cat.runGit(e); err != nil {
+		log.Errorf("Failed to fetch the catalog from git repo %s, error: %v", cat.URL, err)
+		return err
+	}
 
-	e = exec.Command("git", "-C", cat.catalogRoot, "submodule", "update", "--init", "--recursive")
+	e = exec.Command("git", "-C", cat.catalogRoot, "checkout", "--quiet", "--force", cat.URLBranch)
+	if err := cat.runGit(e); err != nil {
+		log.Errorf("Failed to check out the branch %s of the catalog %s, error: %v", cat.URLBranch, cat.CatalogID, err)
+		return err
+	}
 
-	err = e.Run()
-	if err != nil {
-		log.Errorf("Failed to update submodules of the catalog from git repo %s, error: %v", cat.URL, err.Error())
+	e = exec.Command("git", "-C", cat.catalogRoot, "reset", "--quiet", "--hard", "origin/"+cat.URLBranch)
+	if err := cat.runGit(e); err != nil {
+		log.Errorf("Failed to reset the catalog %s to origin/%s, error: %v", cat.CatalogID, cat.URLBranch, err)
 		return err
 	}
-	cat.LastUpdated = time.Now().Format(time.RFC3339)
-	cat.State = "active"
 	return nil
 }
 
 func (cat *Catalog) refreshCatalog() {
-	//put msg on channel, so that any other request can wait
-	select {
-	case *cat.refreshReqChannel <- 1:
-		err := cat.pullCatalog()
-		if err == nil {
+	if cat.embedded {
+		log.Debugf("Catalog %s is embedded in the binary, there is nothing to refresh", cat.getID())
+		return
+	}
+	//register the refresh, so that any other request can find it in progress
+	if !startRefresh(cat.CatalogID) {
+		log.Infof("Refresh for this catalog %s is already in process, it will be refreshed again once it completes", cat.getID())
+		return
+	}
+	for {
+		if err := cat.refreshRegisteredCatalog(); err == nil {
+			markRefreshSucceeded(cat.CatalogID)
+		}
+		if !finishRefresh(cat.CatalogID) {
+			return
+		}
+		log.Infof("Refreshing the catalog %s again, a refresh was requested while it was being refreshed", cat.getID())
+	}
+}
+
+//refreshRegisteredCatalog pulls and walks the catalog once its refresh is registered, it returns the error that
+//kept the catalog from being refreshed
+func (cat *Catalog) refreshRegisteredCatalog() error {
+	//the worktree of a catalog is lost when the clone it belongs to is cloned again
+	if cat.needsClone || (cat.worktreeOf != "" && !cat.gitRepoHealthy()) {
+		err := cat.cloneCatalog()
+		if err != nil {
+			log.Debugf("Will not refresh the catalog since it still cannot be cloned: %v", err)
+		}
+		return err
+	}
+
+	//abort the pull and the walk if they run past -refreshTimeout
+	cat.refreshDeadline = newRefreshDeadline()
+	defer func() {
+		cat.refreshDeadline = time.Time{}
+	}()
+
+	var err error
+	if cat.fromObjectStorage() {
+		if err = cat.refreshObjectStorage(); err != nil && err != errRefreshTimeout {
+			log.Debugf("Will not refresh the catalog since syncing it from object storage faced error: %v", err)
+		}
+	} else if cat.fromZipBundles() {
+		if err = cat.refreshZipBundles(); err != nil && err != errRefreshTimeout {
+			log.Debugf("Will not refresh the catalog since extracting its zip bundles faced error: %v", err)
+		}
+	} else if *externalCheckout {
+		if err = cat.refreshExternalCheckout(); err != nil && err != errRefreshTimeout {
+			log.Debugf("Will not refresh the catalog since reading its external checkout faced error: %v", err)
+		}
+	} else if *snapshot {
+		if err = cat.refreshSnapshot(); err != nil && err != errRefreshTimeout {
+			log.Debugf("Will not refresh the catalog since the snapshot refresh faced error: %v", err)
+		}
+	} else {
+		//remember the commit served so far, to go back to it if too many templates of the pulled one fail to parse
+		previousCommit, _ := cat.headCommit()
+		if err = cat.pullCatalog(); err == nil {
 			log.Debugf("Refreshing the catalog %s ...", cat.getID())
-			//walk the catalog and read the metadata to the cache
-			cat.metadata = make(map[string]model.Template)
-			filepath.Walk(cat.catalogRoot, cat.walkCatalog)
+			setRefreshState(cat.CatalogID, refreshStateWalking)
+			//walk the catalog into a copy, so that the previous metadata is kept if the walk is aborted
+			staged := *cat
+			err = staged.loadMetadata()
+			if err == nil {
+				cat.adoptMetadata(&staged)
+			} else if err == errParseFailures {
+				cat.resetToCommit(previousCommit)
+			}
 		} else {
 			log.Debugf("Will not refresh the catalog since Pull Catalog faced error: %v", err)
 		}
-		<-*cat.refreshReqChannel
-	default:
-		log.Infof("Refresh for this catalog %s is already in process, skipping", cat.getID())
 	}
+	if err == errParseFailures {
+		cat.State = "degraded"
+		cat.Message = cat.parseFailureProblem() + ", serving the previous catalog"
+	}
+	if err == errRefreshTimeout {
+		log.Errorf("Refresh of the catalog %s timed out after %d seconds, keeping the previous catalog", cat.getID(), *refreshTimeout)
+	}
+	return err
+}
+
+//templateConfigFiles lists the names of the template config file, config.yml wins if both exist
+var templateConfigFiles = []string{"config.yml", "config.json"}
+
+//templateConfigFileName returns the name of the config file present under the template
+func templateConfigFileName(relativePath string) string {
+	for _, fileName := range templateConfigFiles {
+		if _, err := os.Stat(path.Join(relativePath, fileName)); err == nil {
+			return fileName
+		}
+	}
+	return templateConfigFiles[0]
 }
 
-func readTemplateConfig(relativePath string, template *model.Template) {
-	filename, err := filepath.Abs(relativePath + "/config.yml")
+func readTemplateConfig(relativePath string, template *model.Template) error {
+	configFileName := templateConfigFileName(relativePath)
+	filename, err := filepath.Abs(path.Join(relativePath, configFileName))
 	if err != nil {
 		log.Errorf("Error forming path to config file at path: %s, error: %v", relativePath, err)
+		return err
 	}
 
-	yamlFile, err := ioutil.ReadFile(filename)
+	configContent, err := readFileContent(filename)
 	if err != nil {
 		log.Errorf("Error reading config file under template: %s, error: %v", relativePath, err)
+		return err
+	}
+	configContent = normalizeText(configContent)
+
+	config := make(map[string]interface{})
+
+	//Read the config.yml or config.json file
+	if path.Ext(configFileName) == ".json" {
+		decoder := json.NewDecoder(bytes.NewReader(configContent))
+		decoder.UseNumber()
+		err = decoder.Decode(&config)
 	} else {
-		config := make(map[string]interface{})
+		err = yaml.Unmarshal(configContent, &config)
+		if err == nil {
+			keepConfigLiterals(configContent, config)
+		}
+	}
+	if err != nil {
+		log.Errorf("Error unmarshalling %s under template: %s, error: %v", configFileName, relativePath, err)
+		return err
+	}
 
-		//Read the config.yml file
-		err = yaml.Unmarshal(yamlFile, &config)
-		if err != nil {
-			log.Errorf("Error unmarshalling config.yml under template: %s, error: %v", relativePath, err)
-		} else {
-			template.Name, _ = config["name"].(string)
-			template.Category, _ = config["category"].(string)
-			template.Description, _ = config["description"].(string)
-			template.Version, _ = config["version"].(string)
-			template.Maintainer, _ = config["maintainer"].(string)
-			template.License, _ = config["license"].(string)
-			template.ProjectURL, _ = config["projectURL"].(string)
-			template.IsSystem, _ = config["isSystem"].(string)
-			template.DefaultVersion, _ = config["version"].(string)
-			template.Labels = map[string]string{}
-
-			labels, _ := config["labels"].(map[interface{}]interface{})
-			for k, v := range labels {
-				template.Labels[fmt.Sprint(k)] = fmt.Sprint(v)
+	template.Name = configScalar(config, "name")
+	template.Category = configScalar(config, "category")
+	template.Category = normalizeCategory(template.Category)
+	template.Description = configScalar(config, "description")
+	template.Version = configScalar(config, "version")
+	template.Maintainer = configScalar(config, "maintainer")
+	template.License = configScalar(config, "license")
+	template.ProjectURL = configScalar(config, "projectURL")
+	template.IsSystem = configScalar(config, "isSystem")
+	template.DefaultVersion = configScalar(config, "version")
+	template.LatestVersion = configScalar(config, "latest")
+	template.MinimumRancherVersion = configScalar(config, "minimum_rancher_version")
+	template.MaximumRancherVersion = configScalar(config, "maximum_rancher_version")
+	template.UpgradeFrom = configScalar(config, "upgrade_from")
+	template.MinimumMemory = configScalar(config, "minimumMemory")
+	template.RecommendedMemory = configScalar(config, "recommendedMemory")
+	template.MinimumCPU = configScalar(config, "minimumCPU")
+	template.RecommendedCPU = configScalar(config, "recommendedCPU")
+	template.InstallNotes = configScalar(config, "installNotes")
+	if template.InstallNotes == "" {
+		template.InstallNotes = configScalar(config, "postInstall")
+	}
+	template.Trust = configScalar(config, "trust")
+	if certified, _ := configBool(config, "certified"); certified && template.Trust == "" {
+		template.Trust = model.CertifiedTrust
+	}
+	if weight, ok := configInt(config, "weight"); ok {
+		template.Weight = weight
+	} else {
+		template.Weight, _ = configInt(config, "order")
+	}
+	template.Aliases = configList(config, "aliases")
+	template.Keywords = configList(config, "keywords")
+	template.Dependencies = configList(config, "dependencies")
+	if len(template.Dependencies) == 0 {
+		template.Dependencies = configList(config, "requires")
+	}
+	template.Environments = configList(config, "environments")
+	if len(template.Environments) == 0 {
+		template.Environments = configList(config, "visibility")
+	}
+	template.Labels = map[string]string{}
+
+	switch labels := config["labels"].(type) {
+	case map[interface{}]interface{}:
+		for k, v := range labels {
+			template.Labels[fmt.Sprint(k)] = fmt.Sprint(v)
+		}
+	case map[string]interface{}:
+		for k, v := range labels {
+			template.Labels[k] = fmt.Sprint(v)
+		}
+	}
+	return nil
+}
+
+//configLiteral is the text of a config.yml scalar as written, so that a version like 1.10 is not read as the number 1.1
+type configLiteral string
+
+//UnmarshalYAML keeps the text of a scalar and leaves lists and maps empty
+func (literal *configLiteral) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var text string
+	if err := unmarshal(&text); err == nil {
+		*literal = configLiteral(text)
+	}
+	return nil
+}
+
+//keepConfigLiterals replaces the numbers and booleans of the config.yml by their text as written, so that
+//values like version: 1.0 or isSystem: true are read as the strings they stand for
+func keepConfigLiterals(configContent []byte, config map[string]interface{}) {
+	literals := make(map[string]configLiteral)
+	if err := yaml.Unmarshal(configContent, &literals); err != nil {
+		return
+	}
+	for key, literal := range literals {
+		switch config[key].(type) {
+		case bool, int, int64, uint64, float64:
+			if literal != "" {
+				config[key] = string(literal)
 			}
 		}
 	}
 }
 
+//configScalar returns the config value as a string, so that numbers like 512 or booleans are read as well,
+//the empty string if it is not set or is a list or a map
+func configScalar(config map[string]interface{}, key string) string {
+	switch value := config[key].(type) {
+	case string:
+		return value
+	case json.Number:
+		return value.String()
+	case bool, int, int64, uint64, float64:
+		return fmt.Sprint(value)
+	}
+	return ""
+}
+
+//configInt returns the config value as an integer, false if it is not set or not a whole number
+func configInt(config map[string]interface{}, key string) (int, bool) {
+	switch value := config[key].(type) {
+	case int:
+		return value, true
+	case float64:
+		if value == float64(int(value)) {
+			return int(value), true
+		}
+	case json.Number, string:
+		if number, err := strconv.Atoi(configScalar(config, key)); err == nil {
+			return number, true
+		}
+	}
+	return 0, false
+}
+
+//configBool returns the config value as a boolean, false if it is not set or not a boolean
+func configBool(config map[string]interface{}, key string) (bool, bool) {
+	switch value := config[key].(type) {
+	case bool:
+		return value, true
+	case string:
+		if flag, err := strconv.ParseBool(value); err == nil {
+			return flag, true
+		}
+	}
+	return false, false
+}
+
+//configList returns the config value as a list of strings, skipping the empty ones
+func configList(config map[string]interface{}, key string) []string {
+	values, _ := config[key].([]interface{})
+	var list []string
+	for _, value := range values {
+		if item := strings.TrimSpace(fmt.Sprint(value)); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+func readTemplateQuestions(relativePath string, template *model.Template) error {
+	composeFileName := rancherComposeFileName(relativePath)
+	composeBytes, err := readFile(relativePath, composeFileName)
+	if err != nil {
+		//questions at the template level are optional
+		return nil
+	}
+
+	catalogConfig, err := parseRancherCompose(*composeBytes)
+	if err != nil {
+		log.Errorf("Error reading questions from %s under template: %s, error: %v", composeFileName, relativePath, err)
+		return err
+	}
+	template.Questions = catalogConfig.Questions
+	template.QuestionsSchema = catalogConfig.QuestionsVersion
+	return nil
+}
+
 func readRancherCompose(relativePath string, newTemplate *model.Template) error {
 
-	composeBytes, err := readFile(relativePath, "rancher-compose.yml")
+	composeBytes, err := readFile(relativePath, rancherComposeFileName(relativePath))
 	if err != nil {
 		return err
 	}
 
-	catalogConfig, err := lookup.ParseCatalogConfig(*composeBytes)
+	catalogConfig, err := parseRancherCompose(*composeBytes)
 	if err != nil {
 		return err
 	}
@@ -301,16 +895,115 @@ func readRancherCompose(relativePath string, newTemplate *model.Template) error
 	newTemplate.MinimumRancherVersion = catalogConfig.MinimumRancherVersion
 	newTemplate.Output = catalogConfig.Output
 	newTemplate.Labels = catalogConfig.Labels
-	binding, err := model.CreateBindings(relativePath)
+	dockerComposeFile := path.Join(relativePath, dockerComposeFileName(relativePath))
+	if err := checkFileSize(dockerComposeFile); err != nil {
+		return err
+	}
+	binding, err := model.CreateBindingsFromFile(dockerComposeFile)
 	if err != nil {
 		return err
 	}
 	newTemplate.Bindings = binding
 	newTemplate.MaximumRancherVersion = catalogConfig.MaximumRancherVersion
 	newTemplate.UpgradeFrom = catalogConfig.UpgradeFrom
+	newTemplate.Stage = catalogConfig.Stage
+	newTemplate.Yanked = catalogConfig.Yanked
+	newTemplate.QuestionsSchema = catalogConfig.QuestionsVersion
+	if newTemplate.Stage == "" {
+		newTemplate.Stage = model.DefaultStage
+	}
 	return nil
 }
 
+//parseRancherCompose reads the catalog config of every document of a rancher-compose file and merges them,
+//the first document setting a property wins
+func parseRancherCompose(composeBytes []byte) (*model.RancherCompose, error) {
+	catalogConfig := &model.RancherCompose{}
+	for _, document := range model.SplitYAMLDocuments(composeBytes) {
+		documentConfig, err := lookup.ParseCatalogConfig(document)
+		if err != nil {
+			return nil, err
+		}
+		readTopLevelVersionConstraints(document, documentConfig)
+		model.MergeRancherCompose(catalogConfig, documentConfig)
+	}
+	return catalogConfig, nil
+}
+
+//readTopLevelVersionConstraints fills in the version constraints missing from the .catalog
+//section with the ones declared at the top level of rancher-compose.yml, if any
+func readTopLevelVersionConstraints(composeBytes []byte, catalogConfig *model.RancherCompose) {
+	topLevel := model.RancherCompose{}
+	err := yaml.Unmarshal(composeBytes, &topLevel)
+	if err != nil {
+		log.Debugf("Cannot read top level version constraints from the rancher-compose file, error: %v", err)
+		return
+	}
+	if catalogConfig.MinimumRancherVersion == "" {
+		catalogConfig.MinimumRancherVersion = topLevel.MinimumRancherVersion
+	}
+	if catalogConfig.MaximumRancherVersion == "" {
+		catalogConfig.MaximumRancherVersion = topLevel.MaximumRancherVersion
+	}
+	if catalogConfig.UpgradeFrom == "" {
+		catalogConfig.UpgradeFrom = topLevel.UpgradeFrom
+	}
+	if catalogConfig.QuestionsVersion == "" {
+		catalogConfig.QuestionsVersion = topLevel.QuestionsVersion
+	}
+}
+
+//inheritVersionConstraints copies the version constraints declared on the parent template
+//onto a template version that does not declare them itself
+func inheritVersionConstraints(template *model.Template, parent *model.Template) {
+	if template.MinimumRancherVersion == "" {
+		template.MinimumRancherVersion = parent.MinimumRancherVersion
+	}
+	if template.MaximumRancherVersion == "" {
+		template.MaximumRancherVersion = parent.MaximumRancherVersion
+	}
+	if template.UpgradeFrom == "" {
+		template.UpgradeFrom = parent.UpgradeFrom
+	}
+	if template.QuestionsSchema == "" {
+		template.QuestionsSchema = parent.QuestionsSchema
+	}
+}
+
+//readLatestPointer returns the name of the version folder the latest pointer of a template points at
+func readLatestPointer(pointerPath string) string {
+	if target, err := os.Readlink(pointerPath); err == nil {
+		return filepath.Base(target)
+	}
+	content, err := readFileContent(pointerPath)
+	if err != nil {
+		log.Debugf("Cannot read the latest pointer %s, error: %v", pointerPath, err)
+		return ""
+	}
+	return strings.TrimSpace(string(content))
+}
+
+//defaultVersionHasQuestions tells whether the default version of a template asks any question, the default
+//version being the one set in config.yml or else the newest one
+func defaultVersionHasQuestions(template *model.Template, versionQuestions map[string]bool) bool {
+	if hasQuestions, ok := versionQuestions[template.DefaultVersion]; ok {
+		return hasQuestions
+	}
+	var newest *semver.Version
+	hasQuestions := false
+	for version, versionHasQuestions := range versionQuestions {
+		semVersion, err := getVersionFromRancherCompose(&model.Template{Version: version})
+		if err != nil {
+			continue
+		}
+		if newest == nil || semVersion.GT(*newest) {
+			newest = semVersion
+			hasQuestions = versionHasQuestions
+		}
+	}
+	return hasQuestions
+}
+
 func readFile(relativePath string, fileName string) (*[]byte, error) {
 	filePath := path.Join(relativePath, fileName)
 	filename, err := filepath.Abs(filePath)
@@ -325,14 +1018,125 @@ func readFile(relativePath string, fileName string) (*[]byte, error) {
 		return nil, err
 	}
 
-	composeBytes, err := ioutil.ReadFile(filename)
+	composeBytes, err := readFileContent(filename)
 	if err != nil {
 		log.Errorf("Error reading file %s, error: %v", filePath, err)
 		return nil, err
 	}
+	composeBytes = normalizeText(composeBytes)
 	return &composeBytes, nil
 }
 
+//readFileContent reads the whole file unless it is larger than the maximum file size
+func readFileContent(filename string) ([]byte, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	if *maxFileSize <= 0 {
+		return ioutil.ReadAll(file)
+	}
+	//read one byte past the limit to tell a file at the limit from a larger one
+	content, err := ioutil.ReadAll(io.LimitReader(file, *maxFileSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(content)) > *maxFileSize {
+		return nil, fmt.Errorf("file is larger than the maximum file size of %d bytes", *maxFileSize)
+	}
+	return content, nil
+}
+
+//normalizeText converts a text file authored on Windows to the UTF-8 with LF line endings the parsers expect:
+//a byte order mark is dropped, UTF-16 is decoded, content that is not valid UTF-8 is read as Latin-1,
+//and CRLF line endings are replaced
+func normalizeText(content []byte) []byte {
+	switch {
+	case bytes.HasPrefix(content, []byte{0xEF, 0xBB, 0xBF}):
+		content = content[3:]
+	case bytes.HasPrefix(content, []byte{0xFF, 0xFE}):
+		content = decodeUTF16(content[2:], binary.LittleEndian)
+	case bytes.HasPrefix(content, []byte{0xFE, 0xFF}):
+		content = decodeUTF16(content[2:], binary.BigEndian)
+	}
+	if !utf8.Valid(content) {
+		runes := make([]rune, len(content))
+		for i, b := range content {
+			runes[i] = rune(b)
+		}
+		content = []byte(string(runes))
+	}
+	return bytes.Replace(content, []byte("\r\n"), []byte("\n"), -1)
+}
+
+//decodeUTF16 decodes UTF-16 content in the given byte order to UTF-8
+func decodeUTF16(content []byte, order binary.ByteOrder) []byte {
+	units := make([]uint16, len(content)/2)
+	for i := range units {
+		units[i] = order.Uint16(content[2*i:])
+	}
+	return []byte(string(utf16.Decode(units)))
+}
+
+//iconTheme returns "light" or "dark" for the themed variants of a catalogIcon file and "" for the plain icon
+func iconTheme(fileName string) string {
+	name := strings.TrimPrefix(fileName, "catalogIcon")
+	if strings.HasPrefix(name, "-light.") {
+		return "light"
+	} else if strings.HasPrefix(name, "-dark.") {
+		return "dark"
+	}
+	return ""
+}
+
+//setTemplateIcons picks the icons of a template among the catalogIcon files of its folder; the light variant
+//is preferred over the plain icon, and a template without a dark variant gets its regular icon in dark mode too
+func setTemplateIcons(template *model.Template, iconFiles []string) bool {
+	var plainIcon, lightIcon, darkIcon string
+	for _, fileName := range iconFiles {
+		switch iconTheme(fileName) {
+		case "light":
+			lightIcon = fileName
+		case "dark":
+			darkIcon = fileName
+		default:
+			plainIcon = fileName
+		}
+	}
+
+	icon := lightIcon
+	if icon == "" {
+		icon = plainIcon
+	}
+	if icon == "" {
+		icon = darkIcon
+	}
+	if icon == "" {
+		return false
+	}
+
+	template.IconLink = template.Id + "?image"
+	template.IconLinkDark = template.Id + "?image&theme=dark"
+	PathToImage.set(template.Path, icon)
+	if darkIcon != "" {
+		PathToDarkImage.set(template.Path, darkIcon)
+	} else {
+		PathToDarkImage.remove(template.Path)
+	}
+	return true
+}
+
+//checkFileSize returns an error if the file exists and is larger than the maximum file size
+func checkFileSize(filename string) error {
+	info, err := os.Stat(filename)
+	if err != nil || *maxFileSize <= 0 || info.Size() <= *maxFileSize {
+		return nil
+	}
+	return fmt.Errorf("file %s is larger than the maximum file size of %d bytes", filename, *maxFileSize)
+}
+
 //ExtractTemplatePrefixAndName reads the template prefix and name
 func ExtractTemplatePrefixAndName(templateID string) (string, string) {
 	var prefix, suffix string
@@ -346,14 +1150,23 @@ func ExtractTemplatePrefixAndName(templateID string) (string, string) {
 	return prefix, suffix
 }
 
-//ReadTemplateVersion reads the template version details
-func (cat *Catalog) ReadTemplateVersion(templateID string, versionID string) (*model.Template, bool) {
+//ReadTemplateVersion reads the template version details, logging any problem to the given logger
+func (cat *Catalog) ReadTemplateVersion(logger *log.Entry, templateID string, versionID string) (*model.Template, bool) {
+	templateID = cat.resolveAlias(templateID)
 
-	prefix, templateName := ExtractTemplatePrefixAndName(templateID)
+	prefix, templateName := cat.templateFolder(templateID)
 	path := cat.CatalogID + "/" + prefix + "/" + templateName + "/" + versionID
 	parentPath := cat.CatalogID + "/" + templateID
 	parentMetadata, ok := cat.metadata[parentPath]
 
+	if helmVersion, found := cat.helmVersions[parentPath][versionID]; ok && found {
+		if err := transformTemplate(&helmVersion); err != nil {
+			logger.Warnf("Skipping the template version %s, %v", helmVersion.Path, err)
+			return nil, false
+		}
+		return &helmVersion, true
+	}
+
 	if ok {
 		newTemplate := model.Template{}
 		newTemplate.Path = cat.CatalogID + "/" + templateID + "/" + versionID
@@ -363,18 +1176,76 @@ func (cat *Catalog) ReadTemplateVersion(templateID string, versionID string) (*m
 		newTemplate.DefaultVersion = parentMetadata.DefaultVersion
 		newTemplate.Category = parentMetadata.Category
 		newTemplate.IsSystem = parentMetadata.IsSystem
+		newTemplate.MinimumMemory = parentMetadata.MinimumMemory
+		newTemplate.RecommendedMemory = parentMetadata.RecommendedMemory
+		newTemplate.MinimumCPU = parentMetadata.MinimumCPU
+		newTemplate.RecommendedCPU = parentMetadata.RecommendedCPU
+		newTemplate.Trust = parentMetadata.Trust
+		newTemplate.Weight = parentMetadata.Weight
+		newTemplate.Dependencies = parentMetadata.Dependencies
+		newTemplate.Keywords = parentMetadata.Keywords
+		newTemplate.Environments = parentMetadata.Environments
+		newTemplate.LatestVersion = parentMetadata.LatestVersion
 		newTemplate.Files = make(map[string]string)
 
-		foundIcon, foundReadme, err := walkVersion(CatalogRootDir+path, &newTemplate)
+		foundIcon, foundReadme, err := walkVersion(logger, CatalogRootDir+path, &newTemplate)
 
 		if err != nil {
-			log.Errorf("Error reading template at path: %s, error: %v", path, err)
+			logger.Errorf("Error reading template at path: %s, error: %v", path, err)
 			return nil, false
 		}
 
+		inheritVersionConstraints(&newTemplate, &parentMetadata)
+
+		sizeBytes, fileCount, err := folderUsage(CatalogRootDir + path)
+		if err != nil {
+			logger.Errorf("Error measuring the size of template at path: %s, error: %v", path, err)
+		}
+		newTemplate.SizeBytes = sizeBytes
+		newTemplate.FileCount = fileCount
+
+		if dockerCompose, ok := versionDockerCompose(newTemplate.Files); ok {
+			images, err := model.ExtractImages([]byte(dockerCompose))
+			if err != nil {
+				logger.Errorf("Error reading the images of template at path: %s, error: %v", path, err)
+			}
+			newTemplate.Images = images
+
+			composeVersion, deprecated, err := model.ComposeVersion([]byte(dockerCompose))
+			if err != nil {
+				logger.Errorf("Error reading the compose version of template at path: %s, error: %v", path, err)
+			}
+			newTemplate.ComposeVersion = composeVersion
+			newTemplate.DeprecatedSyntax = deprecated
+		}
+
+		if notes, ok := newTemplate.Files[installNotesFile]; ok {
+			//the notes are shown after deployment, they are not part of the deployed files
+			newTemplate.InstallNotes = notes
+			delete(newTemplate.Files, installNotesFile)
+		} else {
+			//use the parent notes
+			newTemplate.InstallNotes = parentMetadata.InstallNotes
+		}
+
+		if len(newTemplate.Questions) == 0 {
+			//use the parent questions
+			newTemplate.Questions = parentMetadata.Questions
+		}
+		newTemplate.Questions = model.MergeQuestions(newTemplate.Questions, globalQuestions)
+		newTemplate.HasQuestions = len(newTemplate.Questions) > 0
+		newTemplate.IsLatest = newTemplate.LatestVersion != "" && newTemplate.Version == newTemplate.LatestVersion
+
 		if !foundIcon {
 			//use the parent icon
 			newTemplate.IconLink = parentMetadata.IconLink
+			newTemplate.IconLinkDark = parentMetadata.IconLinkDark
+		}
+
+		newTemplate.Screenshots = screenshotLinks(newTemplate.Id, CatalogRootDir+path)
+		if len(newTemplate.Screenshots) == 0 {
+			//use the parent screenshots
+			newTemplate.Screenshots = parentMetadata.Screenshots
 		}
 
 		if !foundReadme {
@@ -382,6 +1253,10 @@ func (cat *Catalog) ReadTemplateVersion(templateID string, versionID string) (*m
 			newTemplate.ReadmeLink = parentMetadata.ReadmeLink
 		}
 
+		if err := transformTemplate(&newTemplate); err != nil {
+			logger.Warnf("Skipping the template version %s, %v", path, err)
+			return nil, false
+		}
 		return &newTemplate, true
 	}
 
@@ -389,26 +1264,66 @@ func (cat *Catalog) ReadTemplateVersion(templateID string, versionID string) (*m
 
 }
 
-func walkVersion(path string, template *model.Template) (bool, bool, error) {
+//validateTemplateVersions reads every version linked from the catalog templates and returns the parse errors found
+func (cat *Catalog) validateTemplateVersions() []error {
+	var errs []error
+	for _, template := range cat.metadata {
+		for version, link := range template.VersionLinks {
+			tokens := strings.Split(link, ":")
+			if len(tokens) != 3 {
+				errs = append(errs, fmt.Errorf("invalid version link %s for template %s", link, template.Id))
+				continue
+			}
+			templateVersion, ok := cat.ReadTemplateVersion(log.NewEntry(log.StandardLogger()), tokens[1], tokens[2])
+			if !ok {
+				errs = append(errs, fmt.Errorf("cannot read version %s of template %s", version, template.Id))
+				continue
+			}
+			for fileName, content := range templateVersion.Files {
+				if err := validateComposeFile(fileName, content); err != nil {
+					errs = append(errs, fmt.Errorf("error parsing %s of template %s version %s: %v", fileName, template.Id, version, err))
+				}
+			}
+		}
+	}
+	return errs
+}
+
+//validateComposeFile parses the content of a docker-compose or rancher-compose file
+func validateComposeFile(fileName string, content string) error {
+	baseName := path.Base(fileName)
+	var err error
+	if strings.HasPrefix(baseName, "rancher-compose") {
+		_, err = parseRancherCompose([]byte(content))
+	} else if strings.HasPrefix(baseName, "docker-compose") {
+		_, err = model.ExtractBindings([]byte(content))
+	}
+	return err
+}
+
+func walkVersion(logger *log.Entry, path string, template *model.Template) (bool, bool, error) {
 	dirList, err := ioutil.ReadDir(path)
 
 	if err != nil {
-		log.Errorf("Error reading template at path: %s, error: %v", path, err)
+		logger.Errorf("Error reading template at path: %s, error: %v", path, err)
 		return false, false, err
 	}
 
-	var foundIcon, foundReadme bool
+	var foundReadme bool
+	var iconFiles []string
 
 	for _, subfile := range dirList {
+		if isScreenshotEntry(subfile) {
+			//the screenshots are images served on their own, not files of the version
+			continue
+		}
 		if strings.HasPrefix(subfile.Name(), "catalogIcon") {
-			template.IconLink = template.Id + "?image"
-			foundIcon = true
-			PathToImage[template.Path] = subfile.Name()
+			iconFiles = append(iconFiles, subfile.Name())
 
 		} else if strings.HasPrefix(strings.ToLower(subfile.Name()), "readme") {
 			template.ReadmeLink = template.Id + "?readme"
 			foundReadme = true
-			PathToReadme[template.Path] = subfile.Name()
+			PathToReadme.set(template.Path, subfile.Name())
 
 		} else {
 			//read if its a file and put it in the files map
@@ -421,18 +1336,39 @@ func walkVersion(path string, template *model.Template) (bool, bool, error) {
 
 				template.Files[key] = string(*bytes)
 				if strings.HasPrefix(subfile.Name(), "rancher-compose") {
-					readRancherCompose(path, template)
+					if err := readRancherCompose(path, template); err != nil {
+						logger.Errorf("Error parsing %s at path: %s, error: %v", subfile.Name(), path, err)
+					}
 				}
 			} else {
 				//grab files under this folder
-				walkVersion(path+"/"+subfile.Name(), template)
+				walkVersion(logger, path+"/"+subfile.Name(), template)
 			}
 		}
 	}
 
+	foundIcon := setTemplateIcons(template, iconFiles)
 	return foundIcon, foundReadme, nil
 }
 
+//folderUsage returns the bytes and the number of the regular files under the folder, the files of a template
+//version whatever the service reads of them
+func folderUsage(folder string) (int64, int, error) {
+	var sizeBytes int64
+	fileCount := 0
+	err := filepath.Walk(folder, func(filePath string, f os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if f.Mode().IsRegular() {
+			sizeBytes += f.Size()
+			fileCount++
+		}
+		return nil
+	})
+	return sizeBytes, fileCount, err
+}
+
 func deriveFilePath(templatePath string, path string) string {
 	//template.Path = prachi/ElasticSearch/0
 	//path = ./DATA/prachi/templates/ElasticSearch/0  return ""
@@ -0,0 +1,53 @@
+package manager
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path"
+
+	log "github.com/Sirupsen/logrus"
+	"gopkg.in/yaml.v2"
+)
+
+//catalogInfoFiles are the files at the root of a catalog repo read for the metadata of the catalog itself,
+//in order of precedence
+var catalogInfoFiles = []string{"catalog.yml", "index.json"}
+
+//catalogInfo holds the metadata a catalog repo describes itself with
+type catalogInfo struct {
+	Name        string `json:"name" yaml:"name"`
+	Description string `json:"description" yaml:"description"`
+	Logo        string `json:"logo" yaml:"logo"`
+}
+
+//readCatalogInfo reads the name, description and logo of the catalog from the first catalog info file
+//present at the root of the repo, leaving them empty if there is none
+func (cat *Catalog) readCatalogInfo() {
+	cat.Name, cat.Description, cat.Logo = "", "", ""
+	for _, fileName := range catalogInfoFiles {
+		content, err := ioutil.ReadFile(path.Join(cat.catalogRoot, fileName))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			log.Warnf("Failed to read %s of catalog %v, error: %v", fileName, cat.CatalogID, err)
+			return
+		}
+
+		info := catalogInfo{}
+		if path.Ext(fileName) == ".json" {
+			err = json.Unmarshal(content, &info)
+		} else {
+			err = yaml.Unmarshal(content, &info)
+		}
+		if err != nil {
+			log.Warnf("Failed to parse %s of catalog %v, error: %v", fileName, cat.CatalogID, err)
+			return
+		}
+		cat.Name = info.Name
+		cat.Description = info.Description
+		cat.Logo = info.Logo
+		return
+	}
+}
@@ -5,10 +5,13 @@ import (
 	"flag"
 	"fmt"
 	"io/ioutil"
+	"math/rand"
 	"os"
 	"os/signal"
 	"path"
+	"sort"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
@@ -16,6 +19,7 @@ import (
 	"github.com/blang/semver"
 	"github.com/rancher/go-rancher/client"
 	"github.com/rancher/rancher-catalog-service/model"
+	"gopkg.in/yaml.v2"
 )
 
 type arrayFlags []string
@@ -28,7 +32,7 @@ type CatalogInput struct {
 
 //ConfigFileFields stores catalogs
 type ConfigFileFields struct {
-	Catalogs map[string]CatalogInput
+	Catalogs map[string]CatalogInput `json:"catalogs"`
 }
 
 func (i *arrayFlags) String() string {
@@ -41,24 +45,71 @@ func (i *arrayFlags) Set(value string) error {
 }
 
 var (
-	refreshInterval = flag.Int64("refreshInterval", 60, "Time interval (in Seconds) to periodically pull the catalog from git repo")
-	logFile         = flag.String("logFile", "", "Log file")
-	debug           = flag.Bool("debug", false, "Debug")
-	validate        = flag.Bool("validate", false, "Validate catalog yaml and exit")
-	configFile      = flag.String("configFile", "", "Config file")
+	refreshInterval          = flag.Int64("refreshInterval", 60, "Time interval (in Seconds) to periodically pull the catalog from git repo")
+	staleRefreshAge          = flag.Int64("staleRefreshAge", 0, "Age (in Seconds) of the last catalog refresh past which listing the templates starts a background refresh, the stale catalog being served meanwhile; 0 to disable")
+	refreshJitter            = flag.Int("refreshJitter", 0, "Percentage, up to 100, by which each background refresh interval is randomly shortened or lengthened to spread the pulls of several instances")
+	lsRemotePoll             = flag.Bool("lsRemotePoll", false, "Make the background poll run git ls-remote first and only pull and walk the catalogs whose remote branch moved")
+	githubAPIPoll            = flag.Bool("githubAPIPoll", false, "With -lsRemotePoll, read the branch head of the catalogs hosted on GitHub from the GitHub API instead of git ls-remote, falling back to git ls-remote if the API fails")
+	userAgent                = flag.String("userAgent", "rancher-catalog-service/"+Version, "User-Agent sent with all outbound HTTP requests, also sent by the git commands when set")
+	refreshTimeout           = flag.Int64("refreshTimeout", 0, "Time (in Seconds) a catalog refresh may take to pull and walk the catalog before it is aborted and the previous catalog kept, 0 for no limit")
+	logFile                  = flag.String("logFile", "", "Log file")
+	debug                    = flag.Bool("debug", false, "Debug")
+	refreshLogLevel          = flag.String("refreshLogLevel", "", "Level (debug, info, warn, error) of the logs of catalog refreshes and walks; defaults to debug with -debug and info otherwise")
+	requestLogLevel          = flag.String("requestLogLevel", "", "Level (debug, info, warn, error) of the logs of API request handling; defaults to debug with -debug and info otherwise")
+	validate                 = flag.Bool("validate", false, "Validate catalog yaml and exit")
+	configFile               = flag.String("configFile", "", "Config file")
+	validateVersion          = flag.Bool("validateVersions", false, "Read every template version after the catalog is loaded at startup and report the ones that fail to parse")
+	strict                   = flag.Bool("strict", false, "Exit if any template version fails to parse during -validateVersions")
+	allowedCategories        = flag.String("allowedCategories", "", "Comma separated list of the template categories to load, templates of other categories are left out of the catalog; empty to load all")
+	environment              = flag.String("environment", "", "Name of the environment the service runs in, such as staging or prod, templates whose config.yml lists environments without this one are left out of the catalog; empty to load all")
+	templatesDir             = flag.String("templatesDir", "", "Comma separated list of the folders of the catalog repos holding templates, such as infra-templates,app-templates, walked in order and merged into one catalog, a template whose id is already taken by an earlier folder is skipped; empty to read the templates folder and every <prefix>-templates folder")
+	requiredFilesPolicy      = flag.String("requiredFilesPolicy", "", "YAML or JSON file mapping template categories, or * for all of them, to the file patterns every version folder of their templates must have, such as kubernetes: [manifests/*.yml]; the versions missing one are reported by the diagnostics endpoint")
+	dockerComposeFiles       = flag.String("dockerComposeFiles", "docker-compose.yml,docker-compose.yaml", "Comma separated list of the names of the docker-compose file of a template version in order of precedence, the first one present is read")
+	rancherComposeFiles      = flag.String("rancherComposeFiles", "rancher-compose.yml,rancher-compose.yaml", "Comma separated list of the names of the rancher-compose file of a template version in order of precedence, the first one present is read")
+	categoryMapFile          = flag.String("categoryMap", "", "YAML or JSON file mapping template categories to their canonical names")
+	objectSyncCommand        = flag.String("objectSyncCommand", "", "Command syncing the bucket prefix of a catalog URL like s3://bucket/prefix or gs://bucket/prefix to its folder under DATA, with the {url}, {bucket}, {prefix} and {dir} placeholders and its arguments quoted as in a shell; empty for aws s3 sync or gsutil rsync")
+	objectListCommand        = flag.String("objectListCommand", "", "Command listing the objects under the bucket prefix of a catalog URL along with their ETag or generation, a refresh syncs the catalog again only if the listing changed; empty for aws s3api list-objects-v2 or gsutil ls -a")
+	maxGitOperations         = flag.Int("maxGitOperations", 0, "Number of git commands run at once across the catalog refreshes and the endpoints reading git, more commands wait for one to finish; 0 for no limit")
+	referenceRepo            = flag.String("referenceRepo", "", "Local git repo passed to git clone --reference to share objects with, making clones faster")
+	snapshot                 = flag.Bool("snapshotRefresh", false, "Refresh each catalog in a staging copy and switch to it atomically once it is walked, at the cost of a copy of the catalog per refresh")
+	maxTemplates             = flag.Int("maxTemplates", 0, "Number of templates of a catalog past which a prominent warning is logged, to catch a catalog URL pointing at the wrong repo; 0 for no limit")
+	stopAtMaxTemplates       = flag.Bool("stopAtMaxTemplates", false, "Stop loading the templates of a catalog past -maxTemplates instead of only warning")
+	maxFileSize              = flag.Int64("maxFileSize", 10*1024*1024, "Maximum size in bytes of a catalog file to read, larger files are skipped; 0 for no limit")
+	catalogTagPattern        = flag.String("catalogTagPattern", "", "Serve the catalogs at their highest version tag matching this glob pattern, such as v*, instead of their branch head")
+	externalCheckout         = flag.Bool("externalCheckout", false, "Walk the catalogs already checked out under DATA by an external process instead of cloning and pulling them, a refresh walks a catalog again once its HEAD commit moved")
+	readinessCheckRemote     = flag.Bool("readinessCheckRemote", false, "Make the readiness check also run git ls-remote against the remote of every catalog, so that an unreachable remote fails readiness before the next pull fails")
+	readinessRemoteTimeout   = flag.Int64("readinessRemoteTimeout", 10, "Time (in Seconds) the readiness check may take to reach the catalog remotes, which git ls-remote lists in parallel")
+	branchWorktrees          = flag.Bool("branchWorktrees", false, "Serve the catalogs configured with the same repo url at different branches from worktrees of a single clone of the repo, each refreshed on its own")
+	globalQuestionsFile      = flag.String("globalQuestionsFile", "", "YAML or JSON file listing questions asked by every template version after its own questions, a question of the template taking precedence over a global question of the same variable")
+	maxParseFailureRatio     = flag.Float64("maxParseFailureRatio", 0, "Ratio, between 0 and 1, of the template configs and versions of a catalog failing to parse past which a refresh keeps serving the previous catalog and the readiness check fails; 0 to disable")
+	caseInsensitiveTemplates = flag.Bool("caseInsensitiveTemplates", false, "Find the templates whatever the case of their id, such as Redis for redis; templates whose ids differ only by case are found by their exact id only")
+	maxStaleness             = flag.Int64("maxStaleness", 0, "Age (in Seconds) of the last successful catalog refresh past which the readiness check fails, so that an instance whose pulls keep failing leaves the load balancer rotation; 0 to disable")
+	resetToRemote            = flag.Bool("resetToRemote", false, "Refresh the catalogs with git fetch and git reset --hard to the remote branch instead of git pull, discarding local changes and following force pushes")
+	requireSignedCommits     = flag.Bool("requireSignedCommits", false, "Only serve catalog commits whose signature git verify-commit accepts, a catalog stays on its last verified commit when a pulled commit fails verification")
+	trustedKeyring           = flag.String("trustedKeyring", "", "GnuPG home directory holding the public keys trusted to sign catalog commits with -requireSignedCommits; empty for the default keyring")
+	registryRewrite          = flag.String("registryRewrite", "", "Comma separated list of registry=mirror mappings, such as docker.io=mirror.internal, pointing the images of the served and rendered docker-compose files at the mirror of the longest registry prefix they start with; raw=true serves the compose files as written")
+	remoteSubmodule          = flag.Bool("submoduleRemote", false, "Update catalog submodules to the latest commit of their remote branch instead of the recorded commit")
 
 	// Port is the listen port of the HTTP server
-	Port              = flag.Int("port", 8088, "HTTP listen port")
-	refreshReqChannel = make(chan int, 1)
+	Port = flag.Int("port", 8088, "HTTP listen port")
+	// ListenSocket is the path of a Unix domain socket to serve on instead of the TCP port
+	ListenSocket = flag.String("listenSocket", "", "Path of a Unix domain socket to listen on instead of the HTTP listen port, created at startup and removed at shutdown")
+	// MaxInFlightReads is the maximum number of concurrent template version and file reads
+	MaxInFlightReads = flag.Int("maxInFlightReads", 0, "Maximum number of concurrent template version and file reads, 0 for unlimited")
+	// CorsOrigins lists the origins allowed to call the API from a browser
+	CorsOrigins = flag.String("corsOrigins", "", "Comma separated list of origins allowed to make cross-origin requests, * for any origin; no CORS headers are sent if empty")
+
 	//CatalogsCollection is the map storing template catalogs
 	CatalogsCollection map[string]*Catalog
 	//UpdatedCatalogsCollection is the map storing updated template catalogs
 	UpdatedCatalogsCollection map[string]*Catalog
 
 	//PathToImage holds the mapping between a template path in the repo to its image name
-	PathToImage map[string]string
+	PathToImage = newFileNames()
+	//PathToDarkImage holds the mapping between a template path in the repo to its dark theme image name
+	PathToDarkImage = newFileNames()
 	//PathToReadme holds the mapping between a template path in the repo to its readme file name
-	PathToReadme map[string]string
+	PathToReadme = newFileNames()
 
 	//ValidationMode is used to determine if code is just checking Yaml syntax
 	ValidationMode bool
@@ -72,6 +123,13 @@ var (
 	URLBranchMap map[string]string
 
 	reloadChan = make(chan chan error)
+
+	validateVersionsOnce sync.Once
+
+	//categoryMap holds the mapping between raw template categories and their canonical names
+	categoryMap map[string]string
+	//allowedCategorySet holds the lower cased categories of -allowedCategories, empty if all categories are allowed
+	allowedCategorySet map[string]bool
 )
 
 //CatalogRootDir is the root folder under which all catalogs are cloned
@@ -96,6 +154,7 @@ func GetCommandLine() {
 	flag.Var(&catalogURL, "catalogUrl", "git repo url in the form repo_id=repo_url. Specify the flag multiple times for multiple repos")
 
 	flag.Parse()
+	setGitUserAgent()
 	commandLineURL = catalogURL
 	SetEnv()
 }
@@ -143,29 +202,59 @@ func SetEnv() {
 		}
 	}
 
-	if *debug {
-		log.SetLevel(log.DebugLevel)
+	setLogLevels()
+
+	categoryMap = make(map[string]string)
+	if *categoryMapFile != "" {
+		categoryMapContent, err := ioutil.ReadFile(*categoryMapFile)
+		if err != nil {
+			log.Errorf("Cannot read category map file %s, error: %v", *categoryMapFile, err)
+		} else if err = yaml.Unmarshal(categoryMapContent, &categoryMap); err != nil {
+			log.Errorf("Category map data format invalid, error: %v", err)
+		}
+	}
+
+	setTemplatesDirs()
+	setGitLimit()
+	setRequiredFiles()
+	setComposeFiles()
+	setGlobalQuestions()
+	setRegistryRewrites()
+
+	allowedCategorySet = make(map[string]bool)
+	for _, category := range strings.Split(*allowedCategories, ",") {
+		if category = strings.TrimSpace(category); category != "" {
+			allowedCategorySet[strings.ToLower(normalizeCategory(category))] = true
+		}
 	}
 
 	if *validate {
 		ValidationMode = true
-	} else {
-		//Code to delete non-embedded catalogs
+	} else if !*externalCheckout {
+		//Code to delete non-embedded catalogs, the folders of an external process are left alone
 		setCatalogDirectories := make(map[string]bool)
 		for _, cat := range catalogURL {
 			catalog := strings.Split(cat, "=")[0]
 			setCatalogDirectories[catalog] = true
 		}
+		if extractEmbeddedCatalog != nil {
+			setCatalogDirectories[EmbeddedCatalogID] = true
+		}
 		//get all subdirs under catalogRoot, if they are not part of catalogDirectories then rm -rf
 		clonedCatalogDirectories, _ := ioutil.ReadDir(CatalogRootDir)
 		log.Debugf("Removing deleted catalogs\n")
 		for _, dir := range clonedCatalogDirectories {
 			clonedCatalog := dir.Name()
-			if !setCatalogDirectories[clonedCatalog] {
+			if setCatalogDirectories[snapshotCatalogID(clonedCatalog)] && staleSnapshot(clonedCatalog) {
+				log.Debugf("Removing the stale snapshot %s", clonedCatalog)
+				if err := os.RemoveAll(path.Join(CatalogRootDir, clonedCatalog)); err != nil {
+					log.Errorf("Error %v removing directory %s", err, clonedCatalog)
+				}
+			} else if !setCatalogDirectories[clonedCatalog] && !setCatalogDirectories[snapshotCatalogID(clonedCatalog)] {
 				noPurge := path.Join(CatalogRootDir, clonedCatalog, ".nopurge")
 				_, err := os.Stat(noPurge)
 				if os.IsNotExist(err) {
-					err = os.RemoveAll(path.Join(CatalogRootDir, clonedCatalog))
+					err = removeCatalogRoot(path.Join(CatalogRootDir, clonedCatalog))
 					if err != nil {
 						log.Errorf("Error %v removing directory %s", err, clonedCatalog)
 					}
@@ -186,13 +275,16 @@ func SetEnv() {
 		FullTimestamp: true,
 	}
 	log.SetFormatter(textFormatter)
+	RequestLogger.Out = log.StandardLogger().Out
+	RequestLogger.Formatter = textFormatter
 	if catalogURL != nil {
 		if len(CatalogsCollection) == 0 {
 			CatalogsCollection = make(map[string]*Catalog)
 		}
 		UpdatedCatalogsCollection = make(map[string]*Catalog)
-		PathToImage = make(map[string]string)
-		PathToReadme = make(map[string]string)
+		PathToImage.reset()
+		PathToDarkImage.reset()
+		PathToReadme.reset()
 
 		defaultFound := false
 
@@ -225,47 +317,138 @@ func SetEnv() {
 						newCatalog.URLBranch = catalogURLBranch
 					}
 					newCatalog.URL = url
-					refChan := make(chan int, 1)
-					newCatalog.refreshReqChannel = &refChan
 					newCatalog.catalogRoot = CatalogRootDir + tokens[0]
 					UpdatedCatalogsCollection[tokens[0]] = &newCatalog
 					log.Infof("Using catalog %s=%s", tokens[0], url)
 				}
 			}
 		}
+		setBranchWorktrees(UpdatedCatalogsCollection)
 		CatalogsCollection = UpdatedCatalogsCollection
+	} else if extractEmbeddedCatalog != nil {
+		CatalogsCollection = make(map[string]*Catalog)
+		PathToImage.reset()
+		PathToDarkImage.reset()
+		PathToReadme.reset()
 	} else {
 		CatalogsCollection = make(map[string]*Catalog)
 		err := "Halting Catalog service, Catalog git repo url not provided"
 		log.Info(err)
-		_ = fmt.Errorf(err)
+		_ = fmt.Errorf("%s", err)
+	}
+
+	if embeddedCatalog := newEmbeddedCatalog(); embeddedCatalog != nil {
+		//the catalog baked into the binary is served along with the ones given on the command line
+		CatalogsCollection[EmbeddedCatalogID] = embeddedCatalog
+		log.Infof("Using the catalog baked into the binary as %s", EmbeddedCatalogID)
 	}
 }
 
 //Init clones or pulls the catalog, starts background refresh thread
 func Init() {
-	for _, catalog := range CatalogsCollection {
+	for _, catalog := range catalogsInCloneOrder() {
 		catalog.readCatalog()
 	}
 
 	for _, catalog := range CatalogsCollection {
-		catalog.pullCatalog()
+		markRefreshed(catalog.CatalogID)
+		if catalog.metadata != nil {
+			markRefreshSucceeded(catalog.CatalogID)
+		}
+		if catalog.needsClone || catalog.embedded || catalog.fromObjectStorage() || catalog.fromZipBundles() || *externalCheckout {
+			//there is nothing up to date to pull, the background poll retries the clone
+			continue
+		}
+		if *snapshot {
+			catalog.refreshSnapshot()
+		} else {
+			catalog.pullCatalog()
+		}
+	}
+
+	if *validateVersion {
+		validateVersionsOnce.Do(validateAllTemplateVersions)
 	}
 
 	//start a background timer to pull from the Catalog periodically
 	startCatalogBackgroundPoll()
 }
 
+//validateAllTemplateVersions reads every version of every template and reports the ones that fail to parse
+func validateAllTemplateVersions() {
+	failed := 0
+	for _, catalog := range CatalogsCollection {
+		for _, err := range catalog.validateTemplateVersions() {
+			log.Errorf("Template version validation failed: %v", err)
+			failed++
+		}
+	}
+	if failed > 0 && *strict {
+		log.Fatalf("%d template versions failed to parse, exiting", failed)
+	}
+	log.Infof("Template version validation completed, %d versions failed to parse", failed)
+}
+
+//normalizeCategory returns the canonical name of the given category as per the category map
+func normalizeCategory(category string) string {
+	if canonical, ok := categoryMap[category]; ok {
+		return canonical
+	}
+	for raw, canonical := range categoryMap {
+		if strings.EqualFold(raw, category) {
+			return canonical
+		}
+	}
+	return category
+}
+
+//categoryAllowed checks if templates of the given, already normalized, category are loaded as per -allowedCategories
+func categoryAllowed(category string) bool {
+	return len(allowedCategorySet) == 0 || allowedCategorySet[strings.ToLower(category)]
+}
+
+//environmentVisible checks if a template listing the given environments is loaded as per -environment, a template
+//listing no environment is visible in all of them
+func environmentVisible(environments []string) bool {
+	if *environment == "" || len(environments) == 0 {
+		return true
+	}
+	for _, name := range environments {
+		if strings.EqualFold(name, *environment) {
+			return true
+		}
+	}
+	return false
+}
+
 func startCatalogBackgroundPoll() {
-	ticker := time.NewTicker(time.Duration(*refreshInterval) * time.Second)
+	interval := time.Duration(*refreshInterval) * time.Second
+	random := rand.New(rand.NewSource(time.Now().UnixNano()))
 	go func() {
-		for t := range ticker.C {
+		for {
+			t := <-time.After(jitteredInterval(interval, *refreshJitter, random))
 			log.Debugf("Running background Catalog Refresh Thread at time %s", t)
-			RefreshAllCatalogs()
+			pollCatalogs()
 		}
 	}()
 }
 
+//jitteredInterval shifts the refresh interval by a random offset of up to jitter percent either way,
+//so that instances started together do not all pull the catalogs at the same time
+func jitteredInterval(interval time.Duration, jitter int, random *rand.Rand) time.Duration {
+	if jitter <= 0 {
+		return interval
+	}
+	if jitter > 100 {
+		jitter = 100
+	}
+	offset := time.Duration((random.Float64()*2 - 1) * float64(jitter) / 100 * float64(interval))
+	if interval+offset < time.Second {
+		return time.Second
+	}
+	return interval + offset
+}
+
 //RefreshAllCatalogs refreshes the catalogs by syncing changes from github
 func RefreshAllCatalogs() {
 	for _, catalog := range CatalogsCollection {
@@ -274,6 +457,17 @@ func RefreshAllCatalogs() {
 	}
 }
 
+//RefreshCatalog pulls and walks only the catalog with the given id, it returns false if there is no such catalog
+func RefreshCatalog(catalogID string) bool {
+	catalog, ok := CatalogsCollection[catalogID]
+	if !ok {
+		return false
+	}
+	log.Debugf("Refreshing catalog %s", catalog.getID())
+	catalog.refreshCatalog()
+	return true
+}
+
 //ListAllCatalogs lists the catalog id's and links
 func ListAllCatalogs() []Catalog {
 	var catalogCollection []Catalog
@@ -285,6 +479,9 @@ func ListAllCatalogs() []Catalog {
 			},
 		}
 		catalog.CatalogID = catalogID
+		catalog.Name = cat.Name
+		catalog.Description = cat.Description
+		catalog.Logo = cat.Logo
 		catalog.CatalogLink = catalogID + "/templates"
 		catalog.State = cat.State
 		catalog.URL = cat.URL
@@ -307,6 +504,9 @@ func GetCatalog(catalogID string) (Catalog, bool) {
 	if ok {
 		catalog.Id = cat.CatalogID
 		catalog.CatalogID = cat.CatalogID
+		catalog.Name = cat.Name
+		catalog.Description = cat.Description
+		catalog.Logo = cat.Logo
 		catalog.State = cat.State
 		catalog.URL = cat.URL
 		catalog.URLBranch = cat.URLBranch
@@ -346,15 +546,62 @@ func GetTemplateMetadata(catalogID string, templateID string) (model.Template, b
 	if !ok {
 		return model.Template{}, ok
 	}
-	template, ok := cat.metadata[catalogID+"/"+templateID]
+	template, ok := cat.metadata[catalogID+"/"+cat.resolveAlias(templateID)]
 	return template, ok
 }
 
+//ReadTemplateConfigFile returns the raw content of the config file the template is read from, config.yml or
+//config.json, along with its path in the catalog repo; the error satisfies os.IsNotExist if there is none
+func ReadTemplateConfigFile(catalogID string, templateID string) ([]byte, string, error) {
+	cat, ok := CatalogsCollection[catalogID]
+	if !ok {
+		return nil, "", os.ErrNotExist
+	}
+	templateID = cat.resolveAlias(templateID)
+	if _, ok := cat.metadata[catalogID+"/"+templateID]; !ok {
+		return nil, "", os.ErrNotExist
+	}
+	templatesDir, templateName := cat.templateFolder(templateID)
+	templatePath := path.Join(templatesDir, templateName)
+	configPath := path.Join(templatePath, templateConfigFileName(path.Join(cat.catalogRoot, templatePath)))
+	content, err := readFileContent(path.Join(cat.catalogRoot, configPath))
+	return content, configPath, err
+}
+
+//ListDiagnostics lists the problems found while loading the templates of all catalogs
+func ListDiagnostics() []model.TemplateDiagnostic {
+	var diagnostics []model.TemplateDiagnostic
+	for catalogID, cat := range CatalogsCollection {
+		var templatePaths []string
+		for templatePath := range cat.diagnostics {
+			templatePaths = append(templatePaths, templatePath)
+		}
+		sort.Strings(templatePaths)
+
+		for _, templatePath := range templatePaths {
+			diagnostics = append(diagnostics, model.TemplateDiagnostic{
+				Resource: client.Resource{
+					Type: "templateDiagnostic",
+				},
+				CatalogID:    catalogID,
+				TemplatePath: templatePath,
+				Issues:       cat.diagnostics[templatePath],
+			})
+		}
+	}
+	return diagnostics
+}
+
 //ReadTemplateVersion reads the details of a template version
 func ReadTemplateVersion(catalogID string, templateID string, versionID string) (*model.Template, bool) {
+	return ReadTemplateVersionWithLogger(log.NewEntry(log.StandardLogger()), catalogID, templateID, versionID)
+}
+
+//ReadTemplateVersionWithLogger reads the details of a template version, logging any problem to the given logger
+func ReadTemplateVersionWithLogger(logger *log.Entry, catalogID string, templateID string, versionID string) (*model.Template, bool) {
 	cat, ok := CatalogsCollection[catalogID]
 	if ok {
-		return cat.ReadTemplateVersion(templateID, versionID)
+		return cat.ReadTemplateVersion(logger, templateID, versionID)
 	}
 	return nil, ok
 }
@@ -371,7 +618,7 @@ func GetNewTemplateVersions(path string) (model.Template, bool) {
 
 	cat := CatalogsCollection[catalogID]
 
-	templateName, templateID := ExtractTemplatePrefixAndName(parentPath)
+	templateName, templateID := cat.templateFolder(parentPath)
 	rancherComposePathCurrent := CatalogRootDir + catalogID + "/" + templateName + "/" + templateID + "/" + cVersion
 
 	readRancherCompose(rancherComposePathCurrent, &templateMetadata)
@@ -391,11 +638,12 @@ func GetNewTemplateVersions(path string) (model.Template, bool) {
 				otherVersionTokens := strings.Split(value, ":")
 				oVersion := otherVersionTokens[2]
 
-				templateName, templateID := ExtractTemplatePrefixAndName(parentPath)
+				templateName, templateID := cat.templateFolder(parentPath)
 				rancherComposePathOther := CatalogRootDir + catalogID + "/" + templateName + "/" + templateID + "/" + oVersion
 
 				templateOtherMetaData := model.Template{}
 				readRancherCompose(rancherComposePathOther, &templateOtherMetaData)
+				inheritVersionConstraints(&templateOtherMetaData, &templateMetadata)
 				otherVersion, err := getVersionFromRancherCompose(&templateOtherMetaData)
 				if err != nil {
 					log.Errorf("Error %v getting semVersion ", err)
@@ -408,7 +656,8 @@ func GetNewTemplateVersions(path string) (model.Template, bool) {
 					continue
 				}
 
-				if err == nil && currentVersion.LT(*otherVersion) {
+				//a yanked version is no upgrade target
+				if err == nil && currentVersion.LT(*otherVersion) && !templateOtherMetaData.Yanked {
 					if upgradeRange == nil || upgradeRange(*currentVersion) {
 						copyOfVersionLinks[key] = value
 					}
@@ -425,9 +674,84 @@ func GetNewTemplateVersions(path string) (model.Template, bool) {
 	return templateMetadata, false
 }
 
+//GetUpgradeTargets returns the template with only the versions a deployment at the given version can be
+//upgraded to, that is the versions greater than it whose upgrade_from constraint, if any, admits it,
+//along with those versions ordered by semver
+func GetUpgradeTargets(catalogID string, templateID string, from string) (model.Template, []string, error) {
+	cat, ok := CatalogsCollection[catalogID]
+	if !ok {
+		return model.Template{}, nil, fmt.Errorf("cannot find catalog %s", catalogID)
+	}
+	templateMetadata, ok := cat.metadata[catalogID+"/"+templateID]
+	if !ok {
+		return model.Template{}, nil, fmt.Errorf("cannot find template %s", templateID)
+	}
+
+	currentVersion, err := getVersionFromRancherCompose(&model.Template{Version: from})
+	if err != nil {
+		return model.Template{}, nil, err
+	}
+
+	prefix, templateName := cat.templateFolder(templateID)
+	targets := make(map[string]semver.Version)
+	copyOfVersionLinks := make(map[string]string)
+	for key, value := range templateMetadata.VersionLinks {
+		otherVersion, err := getVersionFromRancherCompose(&model.Template{Version: key})
+		if err != nil || !currentVersion.LT(*otherVersion) {
+			continue
+		}
+
+		otherVersionTokens := strings.Split(value, ":")
+		rancherComposePathOther := CatalogRootDir + catalogID + "/" + prefix + "/" + templateName + "/" + otherVersionTokens[len(otherVersionTokens)-1]
+		templateOtherMetaData := model.Template{}
+		readRancherCompose(rancherComposePathOther, &templateOtherMetaData)
+		inheritVersionConstraints(&templateOtherMetaData, &templateMetadata)
+
+		upgradeRange, err := getUpgradeFrom(&templateOtherMetaData)
+		if err != nil {
+			log.Errorf("Error %v getting semRange ", err)
+			continue
+		}
+		if upgradeRange == nil || upgradeRange(*currentVersion) {
+			copyOfVersionLinks[key] = value
+			targets[key] = *otherVersion
+		}
+	}
+	templateMetadata.VersionLinks = copyOfVersionLinks
+
+	versions := versionsBySemver{semVersions: targets}
+	for key := range targets {
+		versions.keys = append(versions.keys, key)
+	}
+	sort.Sort(versions)
+
+	return templateMetadata, versions.keys, nil
+}
+
+//versionsBySemver sorts template version keys by their semantic version
+type versionsBySemver struct {
+	keys        []string
+	semVersions map[string]semver.Version
+}
+
+func (v versionsBySemver) Len() int      { return len(v.keys) }
+func (v versionsBySemver) Swap(i, j int) { v.keys[i], v.keys[j] = v.keys[j], v.keys[i] }
+func (v versionsBySemver) Less(i, j int) bool {
+	return v.semVersions[v.keys[i]].LT(v.semVersions[v.keys[j]])
+}
+
 func getVersionFromRancherCompose(templateMetaData *model.Template) (*semver.Version, error) {
+	semVersion, err := parseVersion(templateMetaData.Version)
+	if err != nil {
+		log.Errorf("Error %v loading semver for version string %s", err.Error(), templateMetaData.Version)
+		return nil, err
+	}
+	return semVersion, nil
+}
+
+//parseVersion reads a version string as a semantic version, completing the missing minor and patch numbers
+func parseVersion(version string) (*semver.Version, error) {
 	var processedVersion string
-	version := templateMetaData.Version
 	if strings.Count(version, ".") == 1 {
 		preReleaseIndex := strings.Index(version, "-")
 		if preReleaseIndex != -1 {
@@ -463,7 +787,6 @@ func getVersionFromRancherCompose(templateMetaData *model.Template) (*semver.Ver
 	}
 	semVersion, err := semver.Make(version)
 	if err != nil {
-		log.Errorf("Error %v loading semver for version string %s", err.Error(), version)
 		return nil, err
 	}
 	return &semVersion, nil
@@ -0,0 +1,70 @@
+package manager
+
+import (
+	"os/exec"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+//readCommitTimes sets the time of the last commit that modified the folder of every template, reading the
+//history of the templates folders with a single git log so that a walk does not run git per template
+func (cat *Catalog) readCommitTimes() {
+	if cat.embedded || cat.fromObjectStorage() || cat.fromZipBundles() || len(cat.templateDirs) == 0 {
+		return
+	}
+
+	//templateFolders maps the template folders, relative to the catalog root, to the template paths
+	templateFolders := make(map[string]string)
+	templatesDirs := make(map[string]bool)
+	for templatePath, templatesDir := range cat.templateDirs {
+		_, templateName := cat.templateFolder(strings.TrimPrefix(templatePath, cat.CatalogID+"/"))
+		templateFolders[path.Join(templatesDir, templateName)] = templatePath
+		templatesDirs[templatesDir] = true
+	}
+	args := []string{"-C", cat.catalogRoot, "log", "--format=%x00%ct", "--name-only", "--no-renames", "--"}
+	for templatesDir := range templatesDirs {
+		args = append(args, templatesDir)
+	}
+
+	out, err := cat.gitOutput(exec.Command("git", args...))
+	if err != nil {
+		log.Debugf("Cannot read the commit times of the templates of catalog %s, error: %v", cat.CatalogID, err)
+		return
+	}
+
+	commitTimes := make(map[string]time.Time)
+	var commitTime time.Time
+	for _, line := range strings.Split(string(out), "\n") {
+		if strings.HasPrefix(line, "\x00") {
+			seconds, err := strconv.ParseInt(strings.TrimPrefix(line, "\x00"), 10, 64)
+			if err != nil {
+				commitTime = time.Time{}
+				continue
+			}
+			commitTime = time.Unix(seconds, 0).UTC()
+			continue
+		}
+		if line == "" || commitTime.IsZero() {
+			continue
+		}
+		for dir := path.Dir(line); dir != "." && dir != "/"; dir = path.Dir(dir) {
+			if templatePath, ok := templateFolders[dir]; ok {
+				if commitTime.After(commitTimes[templatePath]) {
+					commitTimes[templatePath] = commitTime
+				}
+				break
+			}
+		}
+	}
+
+	for templatePath, commitTime := range commitTimes {
+		if template, ok := cat.metadata[templatePath]; ok {
+			template.UpdatedAt = commitTime.Format(time.RFC3339)
+			cat.metadata[templatePath] = template
+		}
+	}
+}
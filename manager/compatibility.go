@@ -0,0 +1,65 @@
+package manager
+
+import (
+	"sort"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/rancher/go-rancher/client"
+	"github.com/rancher/rancher-catalog-service/model"
+)
+
+//GetCompatibility reads every version of the template and returns the constraints they declare, a row per
+//version in the order of the versions of the template, it returns false if there is no such template
+func GetCompatibility(logger *log.Entry, catalogID string, templateID string) (model.TemplateCompatibility, bool) {
+	cat, ok := CatalogsCollection[catalogID]
+	if !ok {
+		return model.TemplateCompatibility{}, false
+	}
+	templateID = cat.resolveAlias(templateID)
+	template, ok := cat.metadata[catalogID+"/"+templateID]
+	if !ok {
+		return model.TemplateCompatibility{}, false
+	}
+
+	compatibility := model.TemplateCompatibility{
+		Resource: client.Resource{
+			Type: "templateCompatibility",
+		},
+		TemplateID:    template.Id,
+		Orchestration: template.TemplateBase,
+		Dependencies:  template.Dependencies,
+		Versions:      []model.VersionCompatibility{},
+	}
+
+	versions := template.Versions
+	if len(versions) == 0 {
+		//the charts of a Helm index have no version folders to order
+		for version := range template.VersionLinks {
+			versions = append(versions, version)
+		}
+		sort.Strings(versions)
+	}
+	for _, version := range versions {
+		link, ok := template.VersionLinks[version]
+		if !ok {
+			continue
+		}
+		tokens := strings.Split(link, ":")
+		templateVersion, ok := cat.ReadTemplateVersion(logger, templateID, tokens[len(tokens)-1])
+		if !ok {
+			logger.Warnf("Cannot read version %s of template %s for its compatibility", version, template.Id)
+			continue
+		}
+		compatibility.Versions = append(compatibility.Versions, model.VersionCompatibility{
+			Version:               version,
+			TemplateVersionID:     link,
+			MinimumRancherVersion: templateVersion.MinimumRancherVersion,
+			MaximumRancherVersion: templateVersion.MaximumRancherVersion,
+			UpgradeFrom:           templateVersion.UpgradeFrom,
+			Stage:                 templateVersion.Stage,
+			Yanked:                templateVersion.Yanked,
+		})
+	}
+	return compatibility, true
+}
@@ -0,0 +1,77 @@
+package manager
+
+import (
+	"os"
+	"path"
+	"strings"
+)
+
+var (
+	//dockerComposeFileNames lists the names of the docker-compose file of a template version in order of
+	//precedence, the first one present is the one read
+	dockerComposeFileNames = []string{"docker-compose.yml"}
+	//rancherComposeFileNames lists the names of the rancher-compose file of a template version in order of
+	//precedence, the first one present is the one read
+	rancherComposeFileNames = []string{"rancher-compose.yml"}
+)
+
+//setComposeFiles reads the precedence of the compose file names of -dockerComposeFiles and -rancherComposeFiles
+func setComposeFiles() {
+	dockerComposeFileNames = fileNameList(*dockerComposeFiles, "docker-compose.yml")
+	rancherComposeFileNames = fileNameList(*rancherComposeFiles, "rancher-compose.yml")
+}
+
+//fileNameList splits a comma separated list of file names, the default one is used if the list is empty
+func fileNameList(names string, defaultName string) []string {
+	var list []string
+	for _, name := range strings.Split(names, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			list = append(list, name)
+		}
+	}
+	if len(list) == 0 {
+		list = []string{defaultName}
+	}
+	return list
+}
+
+//firstPresentFile returns the first of the file names present in the folder, or the first name if none is
+func firstPresentFile(folder string, fileNames []string) string {
+	for _, fileName := range fileNames {
+		if info, err := os.Stat(path.Join(folder, fileName)); err == nil && !info.IsDir() {
+			return fileName
+		}
+	}
+	return fileNames[0]
+}
+
+//dockerComposeFileName returns the name of the docker-compose file read in the folder
+func dockerComposeFileName(folder string) string {
+	return firstPresentFile(folder, dockerComposeFileNames)
+}
+
+//rancherComposeFileName returns the name of the rancher-compose file read in the folder
+func rancherComposeFileName(folder string) string {
+	return firstPresentFile(folder, rancherComposeFileNames)
+}
+
+//versionDockerCompose returns the content of the docker-compose file of a template version read into files,
+//the first file name in order of precedence that is present
+func versionDockerCompose(files map[string]string) (string, bool) {
+	for _, fileName := range dockerComposeFileNames {
+		if content, ok := files[fileName]; ok {
+			return content, true
+		}
+	}
+	return "", false
+}
+
+//isDockerComposeFile tells if a file of a template version is one of its docker-compose files
+func isDockerComposeFile(fileName string) bool {
+	for _, name := range dockerComposeFileNames {
+		if fileName == name {
+			return true
+		}
+	}
+	return strings.HasPrefix(path.Base(fileName), "docker-compose")
+}
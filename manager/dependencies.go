@@ -0,0 +1,28 @@
+package manager
+
+import (
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+//checkDependencies warns about the templates depending on templates missing from the catalog; a dependency is the
+//id of a template of the same catalog, or catalogId:templateId for a template of another catalog
+func (cat *Catalog) checkDependencies() {
+	for templatePath, template := range cat.metadata {
+		for _, dependency := range template.Dependencies {
+			catalogID, templateID := cat.CatalogID, dependency
+			if tokens := strings.SplitN(dependency, ":", 2); len(tokens) == 2 {
+				catalogID, templateID = tokens[0], tokens[1]
+			}
+			if catalogID != cat.CatalogID {
+				//the other catalog may not be loaded yet
+				continue
+			}
+			if _, ok := cat.metadata[cat.CatalogID+"/"+cat.resolveAlias(templateID)]; !ok {
+				log.Warnf("Template %s depends on the template %s that is not in the catalog", templatePath, dependency)
+				cat.addDiagnostic(templatePath, "Dependency on the missing template: %s", dependency)
+			}
+		}
+	}
+}
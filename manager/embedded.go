@@ -0,0 +1,57 @@
+package manager
+
+import (
+	"os"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+//EmbeddedCatalogID is the id the catalog baked into the binary is served under
+const EmbeddedCatalogID string = "embedded"
+
+//extractEmbeddedCatalog writes the catalog baked into the binary to the given folder,
+//it is only set in binaries built with the embedcatalog tag
+var extractEmbeddedCatalog func(root string) error
+
+//newEmbeddedCatalog returns the catalog baked into the binary, nil if the binary has none
+func newEmbeddedCatalog() *Catalog {
+	if extractEmbeddedCatalog == nil {
+		return nil
+	}
+	return &Catalog{
+		CatalogID:   EmbeddedCatalogID,
+		URL:         EmbeddedCatalogID,
+		URLBranch:   "master",
+		catalogRoot: CatalogRootDir + EmbeddedCatalogID,
+		embedded:    true,
+	}
+}
+
+//readEmbeddedCatalog lays the catalog baked into the binary out under DATA and walks it, git is not involved
+func (cat *Catalog) readEmbeddedCatalog() error {
+	stage := CatalogRootDir + "." + cat.CatalogID + "-clone"
+	os.RemoveAll(stage)
+
+	err := extractEmbeddedCatalog(stage)
+	if err == nil {
+		err = replaceCatalogRoot(stage, cat.catalogRoot)
+	}
+	if err != nil {
+		os.RemoveAll(stage)
+		errorStr := "Failed to extract the embedded catalog err: " + err.Error()
+		log.Error(errorStr)
+		cat.State = "error"
+		cat.Message = errorStr
+		return err
+	}
+
+	log.Infof("Extracted the embedded catalog %s", cat.CatalogID)
+	if err := cat.loadInitialMetadata(); err != nil {
+		return err
+	}
+	cat.LastUpdated = time.Now().Format(time.RFC3339)
+	cat.State = "active"
+	cat.Message = ""
+	return nil
+}
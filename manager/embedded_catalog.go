@@ -0,0 +1,38 @@
+//go:build embedcatalog
+// +build embedcatalog
+
+package manager
+
+import (
+	"embed"
+	"io/fs"
+	"os"
+	"path"
+)
+
+//embeddedCatalogFS holds the catalog found under manager/embedded when the binary is built with the embedcatalog tag
+//go:embed embedded
+var embeddedCatalogFS embed.FS
+
+func init() {
+	extractEmbeddedCatalog = func(root string) error {
+		catalogFS, err := fs.Sub(embeddedCatalogFS, "embedded")
+		if err != nil {
+			return err
+		}
+		return fs.WalkDir(catalogFS, ".", func(name string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			target := path.Join(root, name)
+			if entry.IsDir() {
+				return os.MkdirAll(target, 0755)
+			}
+			content, err := fs.ReadFile(catalogFS, name)
+			if err != nil {
+				return err
+			}
+			return os.WriteFile(target, content, 0644)
+		})
+	}
+}
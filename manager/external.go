@@ -0,0 +1,57 @@
+package manager
+
+import (
+	"fmt"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+//readExternalCheckout walks a catalog checked out under DATA by an external process, without cloning or pulling it
+func (cat *Catalog) readExternalCheckout() error {
+	commit, err := cat.headCommit()
+	if err != nil {
+		errorStr := fmt.Sprintf("Cannot read the HEAD commit of the external checkout of the catalog %s at %s, error: %v", cat.CatalogID, cat.catalogRoot, err)
+		log.Error(errorStr)
+		cat.State = "error"
+		cat.Message = errorStr
+		return err
+	}
+
+	log.Infof("Walking the external checkout of the catalog %s at commit %s", cat.CatalogID, commit)
+	if err := cat.loadInitialMetadata(); err != nil {
+		return err
+	}
+	cat.walkedCommit = commit
+	cat.LastUpdated = time.Now().Format(time.RFC3339)
+	cat.State = "active"
+	cat.Message = ""
+	return nil
+}
+
+//refreshExternalCheckout walks the external checkout of the catalog again if its HEAD commit moved since the last walk
+func (cat *Catalog) refreshExternalCheckout() error {
+	commit, err := cat.headCommit()
+	if err != nil {
+		log.Errorf("Cannot read the HEAD commit of the external checkout of the catalog %s, error: %v", cat.CatalogID, err)
+		return err
+	}
+	if commit == cat.walkedCommit {
+		log.Debugf("The external checkout of the catalog %s is still at commit %s", cat.CatalogID, commit)
+		return nil
+	}
+
+	log.Debugf("The external checkout of the catalog %s moved to commit %s, walking it again", cat.CatalogID, commit)
+	setRefreshState(cat.CatalogID, refreshStateWalking)
+	//walk into a copy, so that the previous metadata is kept if the walk is aborted
+	staged := *cat
+	if err := staged.loadMetadata(); err != nil {
+		return err
+	}
+	cat.adoptMetadata(&staged)
+	cat.walkedCommit = commit
+	cat.LastUpdated = time.Now().Format(time.RFC3339)
+	cat.State = "active"
+	cat.Message = ""
+	return nil
+}
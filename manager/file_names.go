@@ -0,0 +1,40 @@
+package manager
+
+import "sync"
+
+//FileNames maps the template paths in the repo to the names of files under them, such as their icons; it is
+//written by the walks and the template version reads, which run concurrently
+type FileNames struct {
+	lock  sync.RWMutex
+	names map[string]string
+}
+
+func newFileNames() *FileNames {
+	return &FileNames{names: make(map[string]string)}
+}
+
+//Get returns the name of the file of the template path
+func (f *FileNames) Get(templatePath string) (string, bool) {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+	name, ok := f.names[templatePath]
+	return name, ok
+}
+
+func (f *FileNames) set(templatePath string, name string) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.names[templatePath] = name
+}
+
+func (f *FileNames) remove(templatePath string) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	delete(f.names, templatePath)
+}
+
+func (f *FileNames) reset() {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.names = make(map[string]string)
+}
@@ -0,0 +1,48 @@
+package manager
+
+import (
+	"os/exec"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+//gitSlots holds a token per git command running, it is nil if -maxGitOperations sets no limit
+var gitSlots chan struct{}
+
+//setGitLimit sizes the git command slots as per -maxGitOperations
+func setGitLimit() {
+	gitSlots = nil
+	if *maxGitOperations > 0 {
+		gitSlots = make(chan struct{}, *maxGitOperations)
+	}
+}
+
+//acquireGit waits for a free git command slot and returns the function releasing it, so that the git
+//commands of all catalogs and endpoints queue up past -maxGitOperations instead of running at once
+func acquireGit() func() {
+	slots := gitSlots
+	if slots == nil {
+		return func() {}
+	}
+	select {
+	case slots <- struct{}{}:
+	default:
+		log.Debugf("All %d git operation slots are taken, waiting for one", cap(slots))
+		slots <- struct{}{}
+	}
+	return func() {
+		<-slots
+	}
+}
+
+//runCommand runs a git command in one of the git command slots
+func runCommand(e *exec.Cmd) error {
+	defer acquireGit()()
+	return e.Run()
+}
+
+//commandOutput runs a git command in one of the git command slots and returns its standard output
+func commandOutput(e *exec.Cmd) ([]byte, error) {
+	defer acquireGit()()
+	return e.Output()
+}
@@ -0,0 +1,55 @@
+package manager
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+//githubAPIURL is the root of the GitHub REST API
+const githubAPIURL string = "https://api.github.com"
+
+//githubRepo returns the owner and name of the GitHub repo of a catalog URL such as
+//https://github.com/rancher/rancher-catalog.git or git@github.com:rancher/rancher-catalog.git; false if the
+//repo is not on GitHub
+func githubRepo(catalogURL string) (string, string, bool) {
+	var repoPath string
+	switch {
+	case strings.HasPrefix(catalogURL, "git@github.com:"):
+		repoPath = strings.TrimPrefix(catalogURL, "git@github.com:")
+	case strings.HasPrefix(catalogURL, "https://github.com/"):
+		repoPath = strings.TrimPrefix(catalogURL, "https://github.com/")
+	default:
+		return "", "", false
+	}
+	tokens := strings.Split(strings.TrimSuffix(strings.TrimSuffix(repoPath, "/"), ".git"), "/")
+	if len(tokens) != 2 || tokens[0] == "" || tokens[1] == "" {
+		return "", "", false
+	}
+	return tokens[0], tokens[1], true
+}
+
+//githubBranchHead returns the commit at the head of a branch of a GitHub repo, which the GitHub API serves in
+//its raw sha media type for much less than a git ls-remote listing every ref
+func githubBranchHead(owner, repo, branch string) (string, error) {
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s/repos/%s/%s/commits/%s", githubAPIURL, owner, repo, branch), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Accept", "application/vnd.github.v3.sha")
+
+	resp, err := HTTPClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("GitHub API returned %s", resp.Status)
+	}
+	return strings.TrimSpace(string(body)), nil
+}
@@ -0,0 +1,38 @@
+package manager
+
+import (
+	"io/ioutil"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/rancher/rancher-catalog-service/model"
+	"gopkg.in/yaml.v2"
+)
+
+//globalQuestions holds the questions of -globalQuestionsFile asked by every template version, empty if none
+var globalQuestions []model.Question
+
+//setGlobalQuestions reads the global questions file, a YAML or JSON list of questions written like the questions
+//of a rancher-compose file
+func setGlobalQuestions() {
+	globalQuestions = nil
+	if *globalQuestionsFile == "" {
+		return
+	}
+
+	questionsContent, err := ioutil.ReadFile(*globalQuestionsFile)
+	if err != nil {
+		log.Errorf("Cannot read global questions file %s, error: %v", *globalQuestionsFile, err)
+		return
+	}
+	if err = yaml.Unmarshal(questionsContent, &globalQuestions); err != nil {
+		log.Errorf("Global questions data format invalid, error: %v", err)
+		globalQuestions = nil
+		return
+	}
+	for _, question := range globalQuestions {
+		if question.Variable == "" {
+			log.Warnf("A global question of %s has no variable, it is asked by every template but never answered", *globalQuestionsFile)
+		}
+	}
+	log.Infof("Asking the %d questions of %s in every template version", len(globalQuestions), *globalQuestionsFile)
+}
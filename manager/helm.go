@@ -0,0 +1,135 @@
+package manager
+
+import (
+	"path"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/rancher/go-rancher/client"
+	"github.com/rancher/rancher-catalog-service/model"
+	"gopkg.in/yaml.v2"
+)
+
+//helmIndexFile is the name of the file listing the charts of a Helm repository
+const helmIndexFile string = "index.yaml"
+
+type helmIndex struct {
+	APIVersion string                        `yaml:"apiVersion"`
+	Entries    map[string][]helmChartVersion `yaml:"entries"`
+}
+
+type helmChartVersion struct {
+	Name        string           `yaml:"name"`
+	Version     string           `yaml:"version"`
+	AppVersion  string           `yaml:"appVersion"`
+	Description string           `yaml:"description"`
+	Home        string           `yaml:"home"`
+	Icon        string           `yaml:"icon"`
+	URLs        []string         `yaml:"urls"`
+	Maintainers []helmMaintainer `yaml:"maintainers"`
+}
+
+type helmMaintainer struct {
+	Name  string `yaml:"name"`
+	Email string `yaml:"email"`
+}
+
+//readHelmIndex reads the charts listed in a Helm index.yaml at the root of the catalog into templates
+func (cat *Catalog) readHelmIndex() {
+	cat.helmVersions = make(map[string]map[string]model.Template)
+
+	indexBytes, err := readFile(cat.catalogRoot, helmIndexFile)
+	if err != nil {
+		return
+	}
+
+	index := helmIndex{}
+	if err := yaml.Unmarshal(*indexBytes, &index); err != nil {
+		log.Errorf("Error unmarshalling %s of catalog %s, error: %v", helmIndexFile, cat.CatalogID, err)
+		cat.addDiagnostic(cat.CatalogID, "Error reading %s: %v", helmIndexFile, err)
+		return
+	}
+
+	for chartName, chartVersions := range index.Entries {
+		if len(chartVersions) == 0 {
+			continue
+		}
+		templatePath := cat.CatalogID + "/" + chartName
+		if _, exists := cat.metadata[templatePath]; exists {
+			log.Errorf("Skipping chart %s of catalog %s, a template with the same name exists", chartName, cat.CatalogID)
+			cat.addDiagnostic(templatePath, "Skipping chart %s, a template with the same name exists", chartName)
+			continue
+		}
+
+		//charts in an index are listed newest first, the newest is the default
+		newest := chartVersions[0]
+		newTemplate := helmChartTemplate(cat.CatalogID, newest)
+		if !categoryAllowed(newTemplate.Category) {
+			log.Debugf("Skipping chart %s of catalog %s, its category %q is not allowed", chartName, cat.CatalogID, newTemplate.Category)
+			continue
+		}
+		newTemplate.Id = cat.CatalogID + ":" + chartName
+		newTemplate.Path = templatePath
+		newTemplate.DefaultVersion = newest.Version
+		newTemplate.VersionLinks = make(map[string]string)
+		newTemplate.TemplateVersionRancherVersion = make(map[string]string)
+		newTemplate.TemplateVersionRancherVersionGte = make(map[string]string)
+		newTemplate.VersionStages = make(map[string]string)
+
+		versions := make(map[string]model.Template)
+		for _, chartVersion := range chartVersions {
+			if chartVersion.Version == "" || strings.ContainsAny(chartVersion.Version, ":/") {
+				cat.addDiagnostic(templatePath, "Skipping chart version with invalid version %q", chartVersion.Version)
+				continue
+			}
+			versionTemplate := helmChartTemplate(cat.CatalogID, chartVersion)
+			versionTemplate.Id = newTemplate.Id + ":" + chartVersion.Version
+			versionTemplate.Path = path.Join(templatePath, chartVersion.Version)
+			versionTemplate.DefaultVersion = newest.Version
+			versionTemplate.Files = make(map[string]string)
+			versions[chartVersion.Version] = versionTemplate
+
+			newTemplate.VersionLinks[chartVersion.Version] = versionTemplate.Id
+			newTemplate.TemplateVersionRancherVersion[chartVersion.Version] = ""
+			newTemplate.TemplateVersionRancherVersionGte[chartVersion.Version] = ""
+			newTemplate.VersionStages[chartVersion.Version] = versionTemplate.Stage
+		}
+
+		if err := transformTemplate(&newTemplate); err != nil {
+			log.Warnf("Skipping chart %s of catalog %s, %v", chartName, cat.CatalogID, err)
+			cat.addDiagnostic(templatePath, "Skipping the template, %v", err)
+			continue
+		}
+		cat.metadata[templatePath] = newTemplate
+		cat.helmVersions[templatePath] = versions
+	}
+}
+
+//helmChartTemplate maps the fields shared by a chart and its versions onto a template
+func helmChartTemplate(catalogID string, chartVersion helmChartVersion) model.Template {
+	var maintainers []string
+	for _, maintainer := range chartVersion.Maintainers {
+		maintainers = append(maintainers, maintainer.Name)
+	}
+
+	labels := make(map[string]string)
+	if chartVersion.AppVersion != "" {
+		labels["appVersion"] = chartVersion.AppVersion
+	}
+
+	return model.Template{
+		Resource: client.Resource{
+			Type: "template",
+		},
+		CatalogID:   catalogID,
+		Name:        chartVersion.Name,
+		Description: chartVersion.Description,
+		Version:     chartVersion.Version,
+		ProjectURL:  chartVersion.Home,
+		IconLink:    chartVersion.Icon,
+		Maintainer:  strings.Join(maintainers, ", "),
+		ChartURLs:   chartVersion.URLs,
+		Stage:       model.DefaultStage,
+		Labels:      labels,
+	}
+}
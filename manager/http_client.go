@@ -0,0 +1,105 @@
+package manager
+
+import (
+	"flag"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+const (
+	headerRateLimitRemaining string = "X-RateLimit-Remaining"
+	headerRateLimitReset     string = "X-RateLimit-Reset"
+)
+
+//RateLimit is the rate limit an API like GitHub's last reported to the service
+type RateLimit struct {
+	Host      string
+	Remaining int64
+	//Reset is the Unix time at which the limit resets, 0 if the API did not report it
+	Reset int64
+}
+
+var (
+	//HTTPClient is the client used for all outbound HTTP requests, it sets the configured User-Agent
+	HTTPClient = &http.Client{
+		Transport: &userAgentTransport{base: http.DefaultTransport},
+		Timeout:   30 * time.Second,
+	}
+
+	rateLimitsLock sync.Mutex
+	rateLimits     = make(map[string]RateLimit)
+)
+
+type userAgentTransport struct {
+	base http.RoundTripper
+}
+
+func (t *userAgentTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	//a RoundTripper must not modify the request, so set the header on a copy
+	outReq := *req
+	outReq.Header = make(http.Header, len(req.Header)+1)
+	for key, values := range req.Header {
+		outReq.Header[key] = values
+	}
+	outReq.Header.Set("User-Agent", *userAgent)
+
+	resp, err := t.base.RoundTrip(&outReq)
+	if err == nil {
+		recordRateLimit(req, resp)
+	}
+	return resp, err
+}
+
+//setGitUserAgent makes the git commands send the configured User-Agent as well when it is set on the command
+//line, git otherwise sending its own
+func setGitUserAgent() {
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == "userAgent" {
+			os.Setenv("GIT_HTTP_USER_AGENT", *userAgent)
+		}
+	})
+}
+
+//recordRateLimit logs and keeps the rate limit remaining reported by APIs like GitHub's
+func recordRateLimit(req *http.Request, resp *http.Response) {
+	remaining, err := strconv.ParseInt(resp.Header.Get(headerRateLimitRemaining), 10, 64)
+	if err != nil {
+		return
+	}
+	reset, _ := strconv.ParseInt(resp.Header.Get(headerRateLimitReset), 10, 64)
+
+	rateLimitsLock.Lock()
+	rateLimits[req.URL.Host] = RateLimit{Host: req.URL.Host, Remaining: remaining, Reset: reset}
+	rateLimitsLock.Unlock()
+
+	if remaining == 0 {
+		log.Warnf("Rate limit exhausted for %s, resets at %s", req.URL.Host, time.Unix(reset, 0).UTC().Format(time.RFC3339))
+		return
+	}
+	log.Debugf("Rate limit remaining for %s: %d", req.URL.Host, remaining)
+}
+
+//GetRateLimits returns the rate limits last reported by the hosts the service requested, in host order
+func GetRateLimits() []RateLimit {
+	rateLimitsLock.Lock()
+	defer rateLimitsLock.Unlock()
+
+	limits := make([]RateLimit, 0, len(rateLimits))
+	for _, limit := range rateLimits {
+		limits = append(limits, limit)
+	}
+	sort.Sort(rateLimitsByHost(limits))
+	return limits
+}
+
+type rateLimitsByHost []RateLimit
+
+func (l rateLimitsByHost) Len() int           { return len(l) }
+func (l rateLimitsByHost) Swap(i, j int)      { l[i], l[j] = l[j], l[i] }
+func (l rateLimitsByHost) Less(i, j int) bool { return l[i].Host < l[j].Host }
@@ -0,0 +1,33 @@
+package manager
+
+import (
+	log "github.com/Sirupsen/logrus"
+)
+
+//RequestLogger logs the handling of API requests at the -requestLogLevel, the standard logger logs the
+//refreshes and walks of the catalogs at the -refreshLogLevel
+var RequestLogger = log.New()
+
+//setLogLevels sets the levels of the refresh and request loggers, either defaults to debug with -debug and
+//to info otherwise
+func setLogLevels() {
+	defaultLevel := log.InfoLevel
+	if *debug {
+		defaultLevel = log.DebugLevel
+	}
+	log.SetLevel(parseLogLevel("refreshLogLevel", *refreshLogLevel, defaultLevel))
+	RequestLogger.Level = parseLogLevel("requestLogLevel", *requestLogLevel, defaultLevel)
+}
+
+//parseLogLevel returns the level set by the flag, or the default level if the flag is not set or invalid
+func parseLogLevel(flagName string, levelName string, defaultLevel log.Level) log.Level {
+	if levelName == "" {
+		return defaultLevel
+	}
+	level, err := log.ParseLevel(levelName)
+	if err != nil {
+		log.Errorf("Invalid -%s %s, logging at the %s level, error: %v", flagName, levelName, defaultLevel, err)
+		return defaultLevel
+	}
+	return level
+}
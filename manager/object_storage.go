@@ -0,0 +1,170 @@
+package manager
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/flynn/go-shlex"
+)
+
+//objectStorageCommands are the default commands syncing and listing a bucket prefix, by URL scheme,
+//the listing holds the ETag or generation of every object so that a change to any object changes it
+var objectStorageCommands = map[string]struct{ sync, list string }{
+	"s3://": {
+		sync: "aws s3 sync --delete --no-progress {url} {dir}",
+		list: "aws s3api list-objects-v2 --bucket {bucket} --prefix {prefix} --query Contents[].[Key,ETag] --output text",
+	},
+	"gs://": {
+		sync: "gsutil -q -m rsync -r -d {url} {dir}",
+		list: "gsutil ls -a -r {url}",
+	},
+}
+
+//objectStorageScheme returns the scheme of a catalog URL naming a bucket prefix, empty for a git repo
+func objectStorageScheme(catalogURL string) string {
+	for scheme := range objectStorageCommands {
+		if strings.HasPrefix(catalogURL, scheme) {
+			return scheme
+		}
+	}
+	return ""
+}
+
+//fromObjectStorage tells if the catalog is synced from a bucket prefix instead of cloned from a git repo
+func (cat *Catalog) fromObjectStorage() bool {
+	return objectStorageScheme(cat.URL) != ""
+}
+
+//objectStorageCommand returns the -objectSyncCommand or -objectListCommand of the catalog, or the default
+//one for its scheme, with the {url}, {bucket}, {prefix} and {dir} placeholders replaced; the arguments are split
+//as a shell does, so that an argument holding spaces can be quoted
+func (cat *Catalog) objectStorageCommand(command string, defaultCommand string) (*exec.Cmd, error) {
+	if strings.TrimSpace(command) == "" {
+		command = defaultCommand
+	}
+	bucketPath := strings.TrimPrefix(cat.URL, objectStorageScheme(cat.URL))
+	bucket, prefix := bucketPath, ""
+	if i := strings.Index(bucketPath, "/"); i != -1 {
+		bucket, prefix = bucketPath[:i], bucketPath[i+1:]
+	}
+	replacer := strings.NewReplacer("{url}", cat.URL, "{bucket}", bucket, "{prefix}", prefix, "{dir}", cat.catalogRoot)
+
+	//the placeholders are replaced in every argument, so that values holding spaces stay one argument
+	fields, err := shlex.Split(command)
+	if err != nil {
+		return nil, fmt.Errorf("cannot split the command %s: %v", command, err)
+	}
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("the command %s is empty", command)
+	}
+	for i, field := range fields {
+		fields[i] = replacer.Replace(field)
+	}
+	return exec.Command(fields[0], fields[1:]...), nil
+}
+
+//objectListing returns a fingerprint of the objects under the bucket prefix of the catalog
+func (cat *Catalog) objectListing() (string, error) {
+	defaults := objectStorageCommands[objectStorageScheme(cat.URL)]
+	e, err := cat.objectStorageCommand(*objectListCommand, defaults.list)
+	if err != nil {
+		return "", err
+	}
+	e.Stderr = os.Stderr
+	out, err := cat.gitOutput(e)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(out)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+//syncObjectStorage syncs the bucket prefix of the catalog to the catalog root
+func (cat *Catalog) syncObjectStorage() error {
+	if err := os.MkdirAll(cat.catalogRoot, 0755); err != nil {
+		return err
+	}
+	defaults := objectStorageCommands[objectStorageScheme(cat.URL)]
+	e, err := cat.objectStorageCommand(*objectSyncCommand, defaults.sync)
+	if err != nil {
+		return err
+	}
+	e.Stdout = os.Stdout
+	e.Stderr = os.Stderr
+	return cat.runGit(e)
+}
+
+//readObjectStorage syncs the bucket prefix of the catalog to DATA and walks it
+func (cat *Catalog) readObjectStorage() error {
+	listing, err := cat.objectListing()
+	if err == nil {
+		err = cat.syncObjectStorage()
+	}
+	if err != nil {
+		errorStr := fmt.Sprintf("Failed to sync the catalog %s from %s, error: %v", cat.CatalogID, cat.URL, err)
+		log.Error(errorStr)
+		if cat.serveStandby() {
+			cat.State = "degraded"
+			cat.Message = errorStr + ", serving the last known copy of the catalog"
+		} else {
+			cat.State = "error"
+			cat.Message = errorStr
+		}
+		return err
+	}
+
+	log.Infof("Synced the catalog %s from %s", cat.CatalogID, cat.URL)
+	if err := cat.loadInitialMetadata(); err != nil {
+		return err
+	}
+	cat.syncedListing = listing
+	cat.LastUpdated = time.Now().Format(time.RFC3339)
+	cat.State = "active"
+	cat.Message = ""
+	return nil
+}
+
+//refreshObjectStorage syncs and walks the catalog again if any object under its bucket prefix changed since
+//the last sync, as told by the ETags or generations of the objects
+func (cat *Catalog) refreshObjectStorage() error {
+	listing, err := cat.objectListing()
+	if err != nil {
+		log.Errorf("Cannot list the objects of the catalog %s at %s, error: %v", cat.CatalogID, cat.URL, err)
+		return err
+	}
+	if listing == cat.syncedListing {
+		log.Debugf("The objects of the catalog %s are unchanged since the last sync", cat.CatalogID)
+		return nil
+	}
+
+	log.Debugf("The objects of the catalog %s changed, syncing it again", cat.CatalogID)
+	//sync into a snapshot of the catalog, so that the files served keep matching the templates served until the
+	//walk of the snapshot is adopted, and the previous catalog is kept if the walk is aborted
+	staged, err := cat.stageSnapshot()
+	if err != nil {
+		return err
+	}
+	if err := staged.syncObjectStorage(); err != nil {
+		os.RemoveAll(staged.catalogRoot)
+		return err
+	}
+	setRefreshState(cat.CatalogID, refreshStateWalking)
+	if err := staged.loadMetadata(); err != nil {
+		os.RemoveAll(staged.catalogRoot)
+		return err
+	}
+	if err := cat.adoptSnapshot(staged); err != nil {
+		return err
+	}
+	cat.syncedListing = listing
+	cat.LastUpdated = time.Now().Format(time.RFC3339)
+	cat.State = "active"
+	cat.Message = ""
+	return nil
+}
@@ -0,0 +1,88 @@
+package manager
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"sort"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+//errParseFailures is returned by a walk of the catalog in which more than -maxParseFailureRatio of the template
+//configs and versions failed to parse, so that a refresh keeps serving the previous catalog
+var errParseFailures = errors.New("too many templates failed to parse")
+
+//parseFailures holds the problem of the last walk of each catalog that failed too many templates, by catalog id
+var parseFailures = make(map[string]string)
+
+//countParse records that a template config or version was read by the walk, or failed to parse
+func (cat *Catalog) countParse(err error) {
+	cat.parsed++
+	if err != nil {
+		cat.parseFailed++
+	}
+}
+
+//checkParseFailures records whether more than -maxParseFailureRatio of the template configs and versions failed
+//to parse in the walk, and returns errParseFailures if so
+func (cat *Catalog) checkParseFailures() error {
+	problem := ""
+	if *maxParseFailureRatio > 0 && cat.parsed > 0 {
+		if ratio := float64(cat.parseFailed) / float64(cat.parsed); ratio > *maxParseFailureRatio {
+			problem = fmt.Sprintf("%d of the %d template configs and versions of catalog %s failed to parse, more than the ratio of %g allowed",
+				cat.parseFailed, cat.parsed, cat.CatalogID, *maxParseFailureRatio)
+		}
+	}
+
+	refreshesLock.Lock()
+	defer refreshesLock.Unlock()
+	if problem == "" {
+		delete(parseFailures, cat.CatalogID)
+		return nil
+	}
+	log.Error(problem)
+	parseFailures[cat.CatalogID] = problem
+	return errParseFailures
+}
+
+//parseFailureProblems lists the catalogs whose last walk failed too many templates
+func parseFailureProblems() []string {
+	refreshesLock.Lock()
+	defer refreshesLock.Unlock()
+	problems := []string{}
+	for catalogID, problem := range parseFailures {
+		if _, ok := CatalogsCollection[catalogID]; ok {
+			problems = append(problems, problem)
+		}
+	}
+	sort.Strings(problems)
+	return problems
+}
+
+//parseFailureProblem returns the problem of the last walk of the catalog if it failed too many templates
+func (cat *Catalog) parseFailureProblem() string {
+	refreshesLock.Lock()
+	defer refreshesLock.Unlock()
+	return parseFailures[cat.CatalogID]
+}
+
+//resetToCommit moves the checkout of the catalog back to the commit it was served at, so that the versions read
+//from disk match the previous catalog kept in memory; the remote tracking branch is moved back too, so that
+//-lsRemotePoll still sees the remote ahead and pulls the commit again
+func (cat *Catalog) resetToCommit(commit string) {
+	if commit == "" {
+		return
+	}
+	e := exec.Command("git", "-C", cat.catalogRoot, "reset", "--quiet", "--hard", commit)
+	if err := runCommand(e); err != nil {
+		log.Errorf("Failed to reset the catalog %s to the previous commit %s, error: %v", cat.CatalogID, commit, err)
+		return
+	}
+	e = exec.Command("git", "-C", cat.catalogRoot, "update-ref", "refs/remotes/origin/"+cat.URLBranch, commit)
+	if err := runCommand(e); err != nil {
+		log.Errorf("Failed to reset the remote tracking branch %s of the catalog %s, error: %v", cat.URLBranch, cat.CatalogID, err)
+		return
+	}
+	log.Warnf("Reset the catalog %s to the previous commit %s, the pulled commit is pulled again on the next refresh", cat.CatalogID, commit)
+}
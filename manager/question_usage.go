@@ -0,0 +1,93 @@
+package manager
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/rancher/rancher-catalog-service/model"
+)
+
+//questionsCache holds the questions of the default version of every template of a catalog by template id, read
+//once per walk of the catalog
+type questionsCache struct {
+	generation int
+	questions  map[string][]model.Question
+}
+
+var (
+	questionsCacheLock sync.Mutex
+	//questionsCaches holds the questions read from every catalog by catalog id, the ones of a catalog are read
+	//again after it is walked
+	questionsCaches = make(map[string]*questionsCache)
+)
+
+//forgetQuestions drops the questions read from the catalog, so that the next listing reads the walked templates
+func forgetQuestions(catalogID string) {
+	questionsCacheLock.Lock()
+	defer questionsCacheLock.Unlock()
+	cache, ok := questionsCaches[catalogID]
+	if !ok {
+		cache = &questionsCache{}
+		questionsCaches[catalogID] = cache
+	}
+	cache.generation++
+	cache.questions = nil
+}
+
+//ListQuestionUsages lists the distinct question variables asked by the default versions of the templates of all
+//the catalogs, with their types and the templates asking them
+func ListQuestionUsages(logger *log.Entry) []model.QuestionUsage {
+	var catalogIDs []string
+	for catalogID := range CatalogsCollection {
+		catalogIDs = append(catalogIDs, catalogID)
+	}
+	sort.Strings(catalogIDs)
+
+	questionsByTemplate := make(map[string][]model.Question)
+	for _, catalogID := range catalogIDs {
+		for templateID, questions := range CatalogsCollection[catalogID].defaultVersionQuestions(logger) {
+			questionsByTemplate[templateID] = questions
+		}
+	}
+	return model.QuestionUsages(questionsByTemplate)
+}
+
+//defaultVersionQuestions returns the questions of the default version of every template of the catalog, reading
+//the versions unless they were read since the last walk
+func (cat *Catalog) defaultVersionQuestions(logger *log.Entry) map[string][]model.Question {
+	questionsCacheLock.Lock()
+	cache, ok := questionsCaches[cat.CatalogID]
+	if !ok {
+		cache = &questionsCache{}
+		questionsCaches[cat.CatalogID] = cache
+	}
+	generation, questions := cache.generation, cache.questions
+	questionsCacheLock.Unlock()
+	if questions != nil {
+		return questions
+	}
+
+	logger.Debugf("Reading the questions of the default versions of the templates of catalog %s", cat.CatalogID)
+	questions = make(map[string][]model.Question)
+	for templatePath, template := range cat.metadata {
+		if len(template.VersionLinks) == 0 {
+			continue
+		}
+		templateVersion, ok := cat.ReadTemplateVersion(logger, strings.TrimPrefix(templatePath, cat.CatalogID+"/"), defaultVersionFolder(&template))
+		if !ok {
+			logger.Warnf("Cannot read the default version of template %s, its questions are not listed", template.Id)
+			continue
+		}
+		questions[template.Id] = templateVersion.Questions
+	}
+
+	questionsCacheLock.Lock()
+	defer questionsCacheLock.Unlock()
+	//the catalog may have been walked meanwhile, its questions are then read again by the next listing
+	if cache.generation == generation {
+		cache.questions = questions
+	}
+	return questions
+}
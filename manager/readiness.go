@@ -0,0 +1,90 @@
+package manager
+
+import (
+	"fmt"
+	"os/exec"
+	"sort"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+//CheckReadiness returns the problems that keep the service from serving the catalogs, none if it is ready:
+//a catalog that is not loaded, with -readinessCheckRemote a catalog remote that git ls-remote cannot reach
+//within -readinessRemoteTimeout, the remotes being listed in parallel under that one deadline, with -maxStaleness a catalog not refreshed successfully for that long, or with
+//-maxParseFailureRatio a catalog whose last walk failed to parse more templates than that
+func CheckReadiness() []string {
+	problems := []string{}
+	if len(CatalogsCollection) == 0 {
+		return append(problems, "no catalog is loaded")
+	}
+	var remoteChecks []*Catalog
+	for catalogID, cat := range CatalogsCollection {
+		if cat.metadata == nil || cat.State == "error" {
+			problems = append(problems, fmt.Sprintf("catalog %s is not loaded: %s", catalogID, cat.Message))
+			continue
+		}
+		if problem := cat.stalenessProblem(); problem != "" {
+			log.Warn(problem)
+			problems = append(problems, problem)
+		}
+		if *readinessCheckRemote && !cat.embedded && !cat.fromObjectStorage() && !cat.fromZipBundles() && !*externalCheckout {
+			remoteChecks = append(remoteChecks, cat)
+		}
+	}
+	problems = append(problems, checkRemotes(remoteChecks)...)
+	problems = append(problems, parseFailureProblems()...)
+	sort.Strings(problems)
+	return problems
+}
+
+//stalenessProblem tells if the catalog was last refreshed successfully more than -maxStaleness seconds ago
+func (cat *Catalog) stalenessProblem() string {
+	if *maxStaleness <= 0 || cat.embedded {
+		return ""
+	}
+	maxAge := time.Duration(*maxStaleness) * time.Second
+	age, ok := staleness(cat.CatalogID)
+	if !ok {
+		return fmt.Sprintf("catalog %s was never refreshed successfully", cat.CatalogID)
+	}
+	if age > maxAge {
+		return fmt.Sprintf("catalog %s was last refreshed successfully %v ago, more than the %v allowed", cat.CatalogID, age-age%time.Second, maxAge)
+	}
+	return ""
+}
+
+//checkRemotes lists the remotes of the catalogs in parallel, so that the readiness check takes at most
+//-readinessRemoteTimeout however many catalogs there are, and returns the ones that cannot be reached
+func checkRemotes(catalogs []*Catalog) []string {
+	timeout := time.Duration(*readinessRemoteTimeout) * time.Second
+	deadline := time.Now().Add(timeout)
+	results := make(chan string, len(catalogs))
+	for _, cat := range catalogs {
+		go func(cat *Catalog) {
+			if err := cat.checkRemote(deadline, timeout); err != nil {
+				results <- fmt.Sprintf("catalog %s cannot reach its remote %s: %v", cat.CatalogID, cat.URL, err)
+				return
+			}
+			results <- ""
+		}(cat)
+	}
+	problems := []string{}
+	for range catalogs {
+		if problem := <-results; problem != "" {
+			problems = append(problems, problem)
+		}
+	}
+	return problems
+}
+
+//checkRemote lists the branch of the catalog at its remote, to tell whether the next pull can succeed
+func (cat *Catalog) checkRemote(deadline time.Time, timeout time.Duration) error {
+	e := exec.Command("git", "ls-remote", "--exit-code", cat.URL, cat.URLBranch)
+	defer acquireGit()()
+	timedOut, err := runUntil(e, deadline)
+	if timedOut {
+		return fmt.Errorf("git ls-remote timed out after %v", timeout)
+	}
+	return err
+}
@@ -0,0 +1,89 @@
+package manager
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/rancher/go-rancher/client"
+	"github.com/rancher/rancher-catalog-service/model"
+)
+
+const refreshStateCloning string = "cloning"
+
+//ErrRefreshInProgress is returned when the catalogs cannot be recloned while one of them is being refreshed
+var ErrRefreshInProgress = errors.New("a catalog refresh is in progress")
+
+//RecloneAllCatalogs clones every catalog again from scratch and walks it, it returns the new commit of each catalog
+func RecloneAllCatalogs() ([]model.RecloneStatus, error) {
+	if *externalCheckout {
+		return nil, errors.New("the catalogs are checked out by an external process and cannot be recloned")
+	}
+
+	var catalogIDs, worktreeIDs []string
+	for catalogID, cat := range CatalogsCollection {
+		if cat.embedded || cat.fromObjectStorage() || cat.fromZipBundles() {
+			//the embedded catalog and the catalogs synced from object storage or zip bundles have no repo to clone
+			continue
+		}
+		if cat.worktreeOf != "" {
+			worktreeIDs = append(worktreeIDs, catalogID)
+		} else {
+			catalogIDs = append(catalogIDs, catalogID)
+		}
+	}
+	sort.Strings(catalogIDs)
+	//the worktrees are added back once the clones they belong to are cloned again
+	sort.Strings(worktreeIDs)
+	catalogIDs = append(catalogIDs, worktreeIDs...)
+
+	//hold off any refresh until all the catalogs are cloned again
+	var started []string
+	defer func() {
+		for _, catalogID := range started {
+			finishRefresh(catalogID)
+		}
+	}()
+	for _, catalogID := range catalogIDs {
+		if !startRefresh(catalogID) {
+			return nil, ErrRefreshInProgress
+		}
+		setRefreshState(catalogID, refreshStateCloning)
+		started = append(started, catalogID)
+	}
+
+	var statuses []model.RecloneStatus
+	for _, catalogID := range catalogIDs {
+		cat := CatalogsCollection[catalogID]
+		log.Infof("Recloning the catalog %s from scratch", catalogID)
+		if err := cat.cloneCatalog(); err != nil {
+			return nil, fmt.Errorf("failed to reclone the catalog %s: %v", catalogID, err)
+		}
+		sha, err := cat.headCommit()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the commit of the catalog %s: %v", catalogID, err)
+		}
+		statuses = append(statuses, model.RecloneStatus{
+			Resource: client.Resource{
+				Id:   catalogID,
+				Type: "recloneStatus",
+			},
+			CatalogID: catalogID,
+			SHA:       sha,
+		})
+	}
+	return statuses, nil
+}
+
+//headCommit returns the SHA of the commit checked out in the catalog root
+func (cat *Catalog) headCommit() (string, error) {
+	e := exec.Command("git", "-C", cat.catalogRoot, "rev-parse", "HEAD")
+	out, err := commandOutput(e)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
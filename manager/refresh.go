@@ -0,0 +1,145 @@
+package manager
+
+import (
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/rancher/go-rancher/client"
+	"github.com/rancher/rancher-catalog-service/model"
+)
+
+const (
+	refreshStatePulling string = "pulling"
+	refreshStateWalking string = "walking"
+)
+
+type refreshProgress struct {
+	state   string
+	started time.Time
+	//followUp is set when a refresh is requested while this one is in progress, so that the catalog is
+	//refreshed once more when it completes and the changes pushed meanwhile are not missed
+	followUp bool
+}
+
+var (
+	refreshesLock sync.Mutex
+	//refreshes holds the refreshes in progress by catalog id, it outlives the Catalog objects recreated by SetEnv
+	refreshes = make(map[string]*refreshProgress)
+	//lastRefreshes holds the time the last refresh of each catalog started, by catalog id
+	lastRefreshes = make(map[string]time.Time)
+	//lastSuccesses holds the time the last successful refresh of each catalog completed, by catalog id
+	lastSuccesses = make(map[string]time.Time)
+)
+
+//startRefresh registers a refresh of the catalog, it returns false if one is already in progress, in which
+//case the refresh in progress is followed by exactly one more refresh however many are requested meanwhile
+func startRefresh(catalogID string) bool {
+	refreshesLock.Lock()
+	defer refreshesLock.Unlock()
+	if progress, ok := refreshes[catalogID]; ok {
+		progress.followUp = true
+		return false
+	}
+	refreshes[catalogID] = &refreshProgress{
+		state:   refreshStatePulling,
+		started: time.Now(),
+	}
+	lastRefreshes[catalogID] = time.Now()
+	return true
+}
+
+//markRefreshed records that the catalog was just refreshed outside of a registered refresh
+func markRefreshed(catalogID string) {
+	refreshesLock.Lock()
+	defer refreshesLock.Unlock()
+	lastRefreshes[catalogID] = time.Now()
+}
+
+//markRefreshSucceeded records that the catalog was just refreshed successfully, or found up to date
+func markRefreshSucceeded(catalogID string) {
+	refreshesLock.Lock()
+	defer refreshesLock.Unlock()
+	lastSuccesses[catalogID] = time.Now()
+}
+
+//staleness returns how long ago the catalog was last refreshed successfully, and false if it never was
+func staleness(catalogID string) (time.Duration, bool) {
+	refreshesLock.Lock()
+	defer refreshesLock.Unlock()
+	lastSuccess, ok := lastSuccesses[catalogID]
+	if !ok {
+		return 0, false
+	}
+	return time.Since(lastSuccess), true
+}
+
+//RevalidateStaleCatalogs starts a background refresh of the catalogs last refreshed more than -staleRefreshAge
+//seconds ago, for all the catalogs or only the given one; it returns without waiting for the refreshes
+func RevalidateStaleCatalogs(catalogID string) {
+	if *staleRefreshAge <= 0 {
+		return
+	}
+	maxAge := time.Duration(*staleRefreshAge) * time.Second
+
+	for id, catalog := range CatalogsCollection {
+		if catalogID != "" && id != catalogID {
+			continue
+		}
+		refreshesLock.Lock()
+		_, inProgress := refreshes[id]
+		lastRefresh := lastRefreshes[id]
+		refreshesLock.Unlock()
+
+		if !inProgress && time.Since(lastRefresh) > maxAge {
+			log.Debugf("Catalog %s was last refreshed at %s, refreshing it in the background", id, lastRefresh.Format(time.RFC3339))
+			go catalog.refreshCatalog()
+		}
+	}
+}
+
+func setRefreshState(catalogID string, state string) {
+	refreshesLock.Lock()
+	defer refreshesLock.Unlock()
+	if progress, ok := refreshes[catalogID]; ok {
+		progress.state = state
+	}
+}
+
+//finishRefresh unregisters the refresh of the catalog, unless another refresh was requested while it was in
+//progress: the refresh is then registered again as the follow-up one and finishRefresh returns true
+func finishRefresh(catalogID string) bool {
+	refreshesLock.Lock()
+	defer refreshesLock.Unlock()
+	if progress, ok := refreshes[catalogID]; ok && progress.followUp {
+		refreshes[catalogID] = &refreshProgress{
+			state:   refreshStatePulling,
+			started: time.Now(),
+		}
+		lastRefreshes[catalogID] = time.Now()
+		return true
+	}
+	delete(refreshes, catalogID)
+	return false
+}
+
+//RefreshesInProgress lists the catalog refreshes currently in progress
+func RefreshesInProgress() []model.RefreshStatus {
+	refreshesLock.Lock()
+	defer refreshesLock.Unlock()
+	var statuses []model.RefreshStatus
+	for catalogID, progress := range refreshes {
+		statuses = append(statuses, model.RefreshStatus{
+			Resource: client.Resource{
+				Id:   catalogID,
+				Type: "refreshStatus",
+			},
+			CatalogID:      catalogID,
+			State:          progress.state,
+			StartedAt:      progress.started.Format(time.RFC3339),
+			RunningSeconds: int64(time.Since(progress.started).Seconds()),
+			FollowUp:       progress.followUp,
+		})
+	}
+	return statuses
+}
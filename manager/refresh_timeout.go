@@ -0,0 +1,69 @@
+package manager
+
+import (
+	"bytes"
+	"errors"
+	"os/exec"
+	"time"
+)
+
+//errRefreshTimeout is returned by the steps of a refresh still running at the -refreshTimeout deadline
+var errRefreshTimeout = errors.New("the catalog refresh timed out")
+
+//newRefreshDeadline returns the time a refresh starting now has to complete by, the zero time if there is no limit
+func newRefreshDeadline() time.Time {
+	if *refreshTimeout <= 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(time.Duration(*refreshTimeout) * time.Second)
+}
+
+//deadlineExceeded tells if the refresh in progress went past its deadline
+func (cat *Catalog) deadlineExceeded() bool {
+	return !cat.refreshDeadline.IsZero() && time.Now().After(cat.refreshDeadline)
+}
+
+//runGit runs a git command of the refresh in one of the git command slots, killing it if it is still running
+//at the refresh deadline
+func (cat *Catalog) runGit(e *exec.Cmd) error {
+	defer acquireGit()()
+	if cat.refreshDeadline.IsZero() {
+		return e.Run()
+	}
+	if cat.deadlineExceeded() {
+		return errRefreshTimeout
+	}
+	timedOut, err := runUntil(e, cat.refreshDeadline)
+	if timedOut {
+		return errRefreshTimeout
+	}
+	return err
+}
+
+//runUntil runs the command, killing it if it is still running at the deadline, and tells if it was killed
+func runUntil(e *exec.Cmd, deadline time.Time) (bool, error) {
+	if err := e.Start(); err != nil {
+		return false, err
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- e.Wait()
+	}()
+	select {
+	case err := <-done:
+		return false, err
+	case <-time.After(deadline.Sub(time.Now())):
+		e.Process.Kill()
+		<-done
+		return true, nil
+	}
+}
+
+//gitOutput is like runGit and returns the standard output of the command
+func (cat *Catalog) gitOutput(e *exec.Cmd) ([]byte, error) {
+	var out bytes.Buffer
+	e.Stdout = &out
+	err := cat.runGit(e)
+	return out.Bytes(), err
+}
@@ -0,0 +1,61 @@
+package manager
+
+import (
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/rancher/rancher-catalog-service/model"
+)
+
+//registryRewrites maps the registry prefixes of -registryRewrite to their mirrors, empty if images are served as is
+var registryRewrites map[string]string
+
+//setRegistryRewrites reads the comma separated registry=mirror mappings of -registryRewrite
+func setRegistryRewrites() {
+	registryRewrites = make(map[string]string)
+	for _, mapping := range strings.Split(*registryRewrite, ",") {
+		if mapping = strings.TrimSpace(mapping); mapping == "" {
+			continue
+		}
+		tokens := strings.SplitN(mapping, "=", 2)
+		if len(tokens) != 2 || strings.Trim(tokens[0], " /") == "" || strings.Trim(tokens[1], " /") == "" {
+			log.Errorf("Skipping the registry rewrite %s, it is not in the form registry=mirror", mapping)
+			continue
+		}
+		from := model.NormalizeRegistryPrefix(strings.Trim(tokens[0], " /"))
+		registryRewrites[from] = strings.Trim(tokens[1], " /")
+	}
+	if len(registryRewrites) > 0 {
+		log.Infof("Rewriting the image registries of the served compose files: %v", registryRewrites)
+	}
+}
+
+//RewriteImageRegistries points the images of the docker-compose files of a template version at the mirrors of
+//-registryRewrite, the files map is copied as the version may be cached
+func RewriteImageRegistries(template *model.Template) {
+	if len(registryRewrites) == 0 {
+		return
+	}
+	files := make(map[string]string)
+	for fileName, content := range template.Files {
+		if isDockerComposeFile(fileName) {
+			content = model.RewriteComposeImages(content, registryRewrites)
+		}
+		files[fileName] = content
+	}
+	template.Files = files
+
+	var images []string
+	for _, image := range template.Images {
+		images = append(images, model.RewriteImageRegistry(image, registryRewrites))
+	}
+	template.Images = images
+}
+
+//RewriteComposeImages points the images of a docker-compose file at the mirrors of -registryRewrite
+func RewriteComposeImages(fileName string, content string) string {
+	if !isDockerComposeFile(fileName) {
+		return content
+	}
+	return model.RewriteComposeImages(content, registryRewrites)
+}
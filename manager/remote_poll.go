@@ -0,0 +1,71 @@
+package manager
+
+import (
+	"os/exec"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+//pollCatalogs refreshes the catalogs on the background poll, with -lsRemotePoll only the ones whose remote branch
+//moved since they were last pulled, so that an idle catalog costs a git ls-remote instead of a pull and a walk
+func pollCatalogs() {
+	for _, catalog := range CatalogsCollection {
+		if *lsRemotePoll && catalog.pollsRemote() && !catalog.remoteBranchMoved() {
+			log.Debugf("The branch %s of the catalog %s did not move, skipping its refresh", catalog.URLBranch, catalog.getID())
+			markRefreshed(catalog.CatalogID)
+			markRefreshSucceeded(catalog.CatalogID)
+			continue
+		}
+		log.Debugf("Refreshing catalog %s", catalog.getID())
+		catalog.refreshCatalog()
+	}
+}
+
+//pollsRemote tells if the catalog is served at the head of its branch, which git ls-remote tells the move of;
+//catalogs served at a tag, with their submodules at their remote branch, or not cloned by the service are
+//refreshed on every poll
+func (cat *Catalog) pollsRemote() bool {
+	return !cat.embedded && !cat.needsClone && !cat.fromObjectStorage() && !cat.fromZipBundles() && !*externalCheckout &&
+		*catalogTagPattern == "" && !*remoteSubmodule
+}
+
+//remoteBranchMoved tells if the branch of the catalog on its remote is at another commit than the remote
+//tracking branch of the last pull, it returns true if either cannot be read
+func (cat *Catalog) remoteBranchMoved() bool {
+	head, ok := cat.remoteBranchHead()
+	if !ok {
+		return true
+	}
+
+	tracked, err := commandOutput(exec.Command("git", "-C", cat.catalogRoot, "rev-parse", "--verify", "--quiet", "refs/remotes/origin/"+cat.URLBranch))
+	if err != nil {
+		log.Debugf("Cannot read the remote tracking branch %s of the catalog %s, error: %v", cat.URLBranch, cat.getID(), err)
+		return true
+	}
+	return head != strings.TrimSpace(string(tracked))
+}
+
+//remoteBranchHead returns the commit at the head of the branch of the catalog on its remote, read from the
+//GitHub API with -githubAPIPoll for the catalogs hosted on GitHub and with git ls-remote otherwise or when the
+//API fails, such as for a private repo
+func (cat *Catalog) remoteBranchHead() (string, bool) {
+	if owner, repo, ok := githubRepo(cat.URL); ok && *githubAPIPoll {
+		head, err := githubBranchHead(owner, repo, cat.URLBranch)
+		if err == nil && head != "" {
+			return head, true
+		}
+		log.Debugf("Cannot read the branch %s of the catalog %s from the GitHub API, listing it with git instead, error: %v", cat.URLBranch, cat.getID(), err)
+	}
+
+	out, err := commandOutput(exec.Command("git", "ls-remote", "--exit-code", cat.URL, "refs/heads/"+cat.URLBranch))
+	if err != nil {
+		log.Debugf("Cannot list the branch %s of the catalog %s on its remote, error: %v", cat.URLBranch, cat.getID(), err)
+		return "", false
+	}
+	fields := strings.Fields(string(out))
+	if len(fields) == 0 {
+		return "", false
+	}
+	return fields[0], true
+}
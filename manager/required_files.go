@@ -0,0 +1,65 @@
+package manager
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+	"gopkg.in/yaml.v2"
+)
+
+//anyCategory is the key of the required files policy listing the files required of templates of every category
+const anyCategory string = "*"
+
+//requiredFiles holds the file patterns every version folder must have by lower cased template category, as read
+//from -requiredFilesPolicy, empty if no file is required
+var requiredFiles map[string][]string
+
+//setRequiredFiles reads the required files policy, a YAML or JSON file mapping template categories to the file
+//patterns their version folders must have, such as kubernetes: [manifests/*.yml|manifests/*.yaml]
+func setRequiredFiles() {
+	requiredFiles = make(map[string][]string)
+	if *requiredFilesPolicy == "" {
+		return
+	}
+
+	policyContent, err := ioutil.ReadFile(*requiredFilesPolicy)
+	if err != nil {
+		log.Errorf("Cannot read required files policy %s, error: %v", *requiredFilesPolicy, err)
+		return
+	}
+	policy := make(map[string][]string)
+	if err = yaml.Unmarshal(policyContent, &policy); err != nil {
+		log.Errorf("Required files policy data format invalid, error: %v", err)
+		return
+	}
+	for category, patterns := range policy {
+		if category != anyCategory {
+			category = strings.ToLower(normalizeCategory(category))
+		}
+		requiredFiles[category] = append(requiredFiles[category], patterns...)
+	}
+}
+
+//missingRequiredFiles lists the patterns required of templates of the given, already normalized, category that
+//no file of the version folder matches, a pattern like docker-compose.yml|docker-compose.yaml is matched by any
+//of its alternatives
+func missingRequiredFiles(category string, versionPath string) []string {
+	patterns := append(append([]string{}, requiredFiles[anyCategory]...), requiredFiles[strings.ToLower(category)]...)
+	var missing []string
+	for _, pattern := range patterns {
+		found := false
+		for _, alternative := range strings.Split(pattern, "|") {
+			matches, err := filepath.Glob(filepath.Join(versionPath, filepath.FromSlash(strings.TrimSpace(alternative))))
+			if err == nil && len(matches) > 0 {
+				found = true
+				break
+			}
+		}
+		if !found {
+			missing = append(missing, pattern)
+		}
+	}
+	return missing
+}
@@ -0,0 +1,89 @@
+package manager
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"strings"
+)
+
+//screenshotsDir is the folder of a template or version holding its screenshots, next to its screenshot* files
+const screenshotsDir string = "screenshots"
+
+//screenshotTypes maps the extensions of the screenshots served to their content type
+var screenshotTypes = map[string]string{
+	".png":  "image/png",
+	".jpg":  "image/jpeg",
+	".jpeg": "image/jpeg",
+	".gif":  "image/gif",
+	".svg":  "image/svg+xml",
+	".webp": "image/webp",
+}
+
+//screenshotType returns the content type of a screenshot file, empty if the file is no image
+func screenshotType(fileName string) string {
+	return screenshotTypes[strings.ToLower(path.Ext(fileName))]
+}
+
+//isScreenshotEntry tells if a file of a template or version folder is a screenshot or the screenshots folder,
+//neither of which is a file of the template version
+func isScreenshotEntry(file os.FileInfo) bool {
+	if file.IsDir() {
+		return file.Name() == screenshotsDir
+	}
+	return strings.HasPrefix(strings.ToLower(file.Name()), "screenshot") && screenshotType(file.Name()) != ""
+}
+
+//screenshotLinks lists the links of the screenshots of a template or version folder, the screenshot* images of
+//the folder and the images of its screenshots folder, relative to the collection of templates like the icon link
+func screenshotLinks(templateID string, folder string) []string {
+	var files []string
+	dirList, _ := ioutil.ReadDir(folder)
+	for _, file := range dirList {
+		if !file.IsDir() && isScreenshotEntry(file) {
+			files = append(files, file.Name())
+		}
+	}
+	dirList, _ = ioutil.ReadDir(path.Join(folder, screenshotsDir))
+	for _, file := range dirList {
+		if !file.IsDir() && screenshotType(file.Name()) != "" {
+			files = append(files, file.Name())
+		}
+	}
+	sort.Strings(files)
+
+	var links []string
+	seen := make(map[string]bool)
+	for _, file := range files {
+		if !seen[file] {
+			seen[file] = true
+			links = append(links, templateID+"/screenshots/"+file)
+		}
+	}
+	return links
+}
+
+//ScreenshotFile returns the path and the content type of a screenshot of a template, or of a template version if
+//the version is given, looking in the screenshots folder first; it returns false if there is no such screenshot
+func ScreenshotFile(catalogID string, templateID string, versionID string, fileName string) (string, string, bool) {
+	contentType := screenshotType(fileName)
+	if contentType == "" || fileName != path.Base(fileName) || strings.HasPrefix(fileName, ".") {
+		return "", "", false
+	}
+	cat, relativePath, ok := templateRepoPath(catalogID, templateID, versionID)
+	if !ok {
+		return "", "", false
+	}
+
+	candidates := []string{path.Join(cat.catalogRoot, relativePath, screenshotsDir, fileName)}
+	if strings.HasPrefix(strings.ToLower(fileName), "screenshot") {
+		candidates = append(candidates, path.Join(cat.catalogRoot, relativePath, fileName))
+	}
+	for _, candidate := range candidates {
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			return candidate, contentType, true
+		}
+	}
+	return "", "", false
+}
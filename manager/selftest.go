@@ -0,0 +1,141 @@
+package manager
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/blang/semver"
+	"github.com/rancher/go-rancher/client"
+	"github.com/rancher/rancher-catalog-service/model"
+)
+
+//SelfTest reads the default version of the first template of the catalogs, as the api does, and checks that
+//the template config, the compose files and the questions of the version parse
+func SelfTest(logger *log.Entry) model.SelfTest {
+	report := model.SelfTest{
+		Resource: client.Resource{
+			Type: "selfTest",
+		},
+		Checks: []model.SelfTestCheck{},
+	}
+	addCheck := func(name string, err error) {
+		check := model.SelfTestCheck{Name: name, Passed: err == nil}
+		if err != nil {
+			check.Message = err.Error()
+		}
+		report.Checks = append(report.Checks, check)
+	}
+
+	cat, template, ok := firstTemplate()
+	if !ok {
+		addCheck("template", fmt.Errorf("no catalog serves a template"))
+		return report
+	}
+	addCheck("template", nil)
+
+	templateID := strings.TrimPrefix(template.Path, cat.CatalogID+"/")
+	templatesDir, templateName := cat.templateFolder(templateID)
+	addCheck("config", readTemplateConfig(path.Join(cat.catalogRoot, templatesDir, templateName), &model.Template{}))
+
+	versionID := defaultVersionFolder(&template)
+	report.TemplateVersionID = template.Id + ":" + versionID
+	templateVersion, ok := cat.ReadTemplateVersion(logger, templateID, versionID)
+	if !ok {
+		addCheck("version", fmt.Errorf("cannot read the template version %s", report.TemplateVersionID))
+		return report
+	}
+	addCheck("version", nil)
+
+	var composeErr error
+	composeFiles := 0
+	for fileName, content := range templateVersion.Files {
+		if !model.IsComposeFile(fileName) {
+			continue
+		}
+		composeFiles++
+		if err := validateComposeFile(fileName, content); err != nil && composeErr == nil {
+			composeErr = fmt.Errorf("error parsing %s: %v", fileName, err)
+		}
+	}
+	if composeFiles == 0 {
+		composeErr = fmt.Errorf("the template version has no compose file")
+	}
+	addCheck("compose", composeErr)
+
+	addCheck("questions", checkQuestions(templateVersion.Questions))
+
+	report.Passed = true
+	for _, check := range report.Checks {
+		report.Passed = report.Passed && check.Passed
+	}
+	return report
+}
+
+//firstTemplate returns the first template, by catalog and template path, that has versions and is not read
+//from a Helm index
+func firstTemplate() (*Catalog, model.Template, bool) {
+	var catalogIDs []string
+	for catalogID := range CatalogsCollection {
+		catalogIDs = append(catalogIDs, catalogID)
+	}
+	sort.Strings(catalogIDs)
+
+	for _, catalogID := range catalogIDs {
+		cat := CatalogsCollection[catalogID]
+		var templatePaths []string
+		for templatePath, template := range cat.metadata {
+			if _, isChart := cat.helmVersions[templatePath]; !isChart && len(template.VersionLinks) > 0 {
+				templatePaths = append(templatePaths, templatePath)
+			}
+		}
+		if len(templatePaths) > 0 {
+			sort.Strings(templatePaths)
+			return cat, cat.metadata[templatePaths[0]], true
+		}
+	}
+	return nil, model.Template{}, false
+}
+
+//defaultVersionFolder returns the folder of the default version of the template, or of its newest version
+//if it has no default version
+func defaultVersionFolder(template *model.Template) string {
+	link, ok := template.VersionLinks[template.DefaultVersion]
+	if !ok {
+		var newest *semver.Version
+		for version, versionLink := range template.VersionLinks {
+			semVersion, err := getVersionFromRancherCompose(&model.Template{Version: version})
+			if err != nil {
+				continue
+			}
+			if newest == nil || semVersion.GT(*newest) {
+				newest = semVersion
+				link = versionLink
+			}
+		}
+	}
+	tokens := strings.Split(link, ":")
+	return tokens[len(tokens)-1]
+}
+
+//checkQuestions checks that every question names its variable once and that the defaults meet the
+//validation constraints of the questions
+func checkQuestions(questions []model.Question) error {
+	defaults := make(map[string]string)
+	for _, question := range questions {
+		if question.Variable == "" {
+			return fmt.Errorf("the question %q has no variable", question.Label)
+		}
+		if _, ok := defaults[question.Variable]; ok {
+			return fmt.Errorf("the variable %s is asked twice", question.Variable)
+		}
+		defaults[question.Variable] = question.Default
+	}
+	//a required question without a default is answered at deploy time, only the defaults given are checked
+	if errors := model.ValidateAnswerConstraints(questions, defaults); len(errors) > 0 {
+		return fmt.Errorf("invalid defaults: %s", strings.Join(errors, ", "))
+	}
+	return nil
+}
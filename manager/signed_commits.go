@@ -0,0 +1,68 @@
+package manager
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+//verifyCommit checks that the commit checked out in the repo is signed by a key of the -trustedKeyring
+func verifyCommit(repoDir string) error {
+	e := exec.Command("git", "-C", repoDir, "verify-commit", "HEAD")
+	if *trustedKeyring != "" {
+		e.Env = append(os.Environ(), "GNUPGHOME="+*trustedKeyring)
+	}
+	var stderr bytes.Buffer
+	e.Stderr = &stderr
+	if err := runCommand(e); err != nil {
+		if output := strings.TrimSpace(stderr.String()); output != "" {
+			return fmt.Errorf("commit is not signed by a trusted key: %v, %s", err, output)
+		}
+		return fmt.Errorf("commit is not signed by a trusted key: %v", err)
+	}
+	return nil
+}
+
+//verifyExistingCommit checks the signature of the commit of a catalog already checked out at startup with
+//-requireSignedCommits, the catalog is not walked if it is not signed by a trusted key
+func (cat *Catalog) verifyExistingCommit() error {
+	if !*requireSignedCommits {
+		return nil
+	}
+	if err := verifyCommit(cat.catalogRoot); err != nil {
+		log.Errorf("Refusing to serve the checked out commit of catalog %s, %v", cat.CatalogID, err)
+		cat.State = "error"
+		cat.Message = "Refusing to serve the checked out commit, " + err.Error()
+		return err
+	}
+	cat.verifiedCommit, _ = cat.headCommit()
+	return nil
+}
+
+//verifyPulledCommit checks the signature of the commit just pulled and moves the catalog back to the last
+//verified commit if it is not signed by a trusted key
+func (cat *Catalog) verifyPulledCommit() error {
+	err := verifyCommit(cat.catalogRoot)
+	if err == nil {
+		cat.verifiedCommit, _ = cat.headCommit()
+		cat.Message = ""
+		return nil
+	}
+	log.Errorf("Refusing to serve the pulled commit of catalog %s, %v", cat.CatalogID, err)
+	verifiedCommit := cat.verifiedCommit
+	if verifiedCommit == "" {
+		return err
+	}
+
+	e := exec.Command("git", "-C", cat.catalogRoot, "reset", "--quiet", "--hard", verifiedCommit)
+	if resetErr := runCommand(e); resetErr != nil {
+		log.Errorf("Failed to reset the catalog %s to the verified commit %s, error: %v", cat.CatalogID, verifiedCommit, resetErr)
+		return err
+	}
+	cat.Message = "Serving the verified commit " + verifiedCommit + ", the pulled " + err.Error()
+	return err
+}
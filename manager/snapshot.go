@@ -0,0 +1,171 @@
+package manager
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+//snapshotDir returns a new directory, next to the catalog root, to stage a snapshot of the catalog in
+func (cat *Catalog) snapshotDir() string {
+	return path.Join(CatalogRootDir, fmt.Sprintf(".%s-%d", cat.CatalogID, time.Now().UnixNano()))
+}
+
+//snapshotCatalogID returns the id of the catalog a snapshot directory belongs to, if the name is one
+func snapshotCatalogID(dirName string) string {
+	if !strings.HasPrefix(dirName, ".") {
+		return ""
+	}
+	index := strings.LastIndex(dirName, "-")
+	if index == -1 {
+		return ""
+	}
+	return dirName[1:index]
+}
+
+//snapshotTarget returns the snapshot directory the catalog root points to when it is the symlink of
+//-snapshotRefresh, false if it is not
+func snapshotTarget(catalogRoot string) (string, bool) {
+	info, err := os.Lstat(catalogRoot)
+	if err != nil || info.Mode()&os.ModeSymlink == 0 {
+		return "", false
+	}
+	target, err := filepath.EvalSymlinks(catalogRoot)
+	if err != nil {
+		return "", false
+	}
+	//only a snapshot of the catalog is removed along with the link, never a folder the link was pointed at by hand
+	if filepath.Dir(target) != filepath.Dir(filepath.Clean(catalogRoot)) || snapshotCatalogID(filepath.Base(target)) != filepath.Base(catalogRoot) {
+		return "", false
+	}
+	return target, true
+}
+
+//removeCatalogRoot removes the catalog root along with the snapshot it points to, removing only the symlink of
+//-snapshotRefresh would leave the snapshot behind
+func removeCatalogRoot(catalogRoot string) error {
+	if target, ok := snapshotTarget(catalogRoot); ok {
+		if err := os.RemoveAll(target); err != nil {
+			return err
+		}
+	}
+	return os.RemoveAll(catalogRoot)
+}
+
+//staleSnapshot tells if the directory is a snapshot or clone stage of a served catalog that its catalog root no
+//longer points to, left behind by an interrupted refresh; none is stale while a refresh may be staging one
+func staleSnapshot(dirName string) bool {
+	catalogID := snapshotCatalogID(dirName)
+	if catalogID == "" || len(RefreshesInProgress()) > 0 {
+		return false
+	}
+	target, ok := snapshotTarget(path.Join(CatalogRootDir, catalogID))
+	return !ok || filepath.Base(target) != dirName
+}
+
+//refreshSnapshot pulls the catalog into a staging copy, walks it and then atomically points the catalog
+//root symlink to it, so that readers never see a partially pulled working tree
+func (cat *Catalog) refreshSnapshot() error {
+	staged, err := cat.stageSnapshot()
+	if err != nil {
+		return err
+	}
+	if err := staged.pullCatalog(); err != nil {
+		os.RemoveAll(staged.catalogRoot)
+		return err
+	}
+	setRefreshState(cat.CatalogID, refreshStateWalking)
+	if err := staged.loadMetadata(); err != nil {
+		os.RemoveAll(staged.catalogRoot)
+		return err
+	}
+	if err := cat.adoptSnapshot(staged); err != nil {
+		return err
+	}
+	cat.LastUpdated = staged.LastUpdated
+	cat.State = staged.State
+	cat.verifiedCommit = staged.verifiedCommit
+	return nil
+}
+
+//stageSnapshot copies the catalog root to a new snapshot directory and returns a copy of the catalog rooted there,
+//for a refresh to update and walk
+func (cat *Catalog) stageSnapshot() (*Catalog, error) {
+	current, err := filepath.EvalSymlinks(cat.catalogRoot)
+	if err != nil {
+		log.Errorf("Cannot resolve the catalog root %s, error: %v", cat.catalogRoot, err)
+		return nil, err
+	}
+
+	stage := cat.snapshotDir()
+	log.Debugf("Staging the catalog %s refresh in %s", cat.CatalogID, stage)
+	out, err := exec.Command("cp", "-a", current, stage).CombinedOutput()
+	if err != nil {
+		log.Errorf("Failed to stage the catalog %s to %s, error: %v, %s", cat.CatalogID, stage, err, out)
+		os.RemoveAll(stage)
+		return nil, err
+	}
+
+	staged := *cat
+	staged.catalogRoot = stage
+	return &staged, nil
+}
+
+//adoptSnapshot points the catalog root symlink to the snapshot the staged catalog was walked in, serves the
+//templates walked and removes the previous snapshot
+func (cat *Catalog) adoptSnapshot(staged *Catalog) error {
+	previous, err := swapCatalogRoot(cat.catalogRoot, staged.catalogRoot)
+	if err != nil {
+		log.Errorf("Failed to swap the catalog %s to the refreshed snapshot, error: %v", cat.CatalogID, err)
+		os.RemoveAll(staged.catalogRoot)
+		return err
+	}
+
+	cat.adoptMetadata(staged)
+
+	if previous != "" {
+		if err := os.RemoveAll(previous); err != nil {
+			log.Errorf("Error %v removing the previous snapshot %s", err, previous)
+		}
+	}
+	return nil
+}
+
+//swapCatalogRoot atomically replaces the catalog root symlink with one pointing to target and
+//returns the directory the root used to point to
+func swapCatalogRoot(catalogRoot string, target string) (string, error) {
+	tmpLink := catalogRoot + ".link"
+	os.Remove(tmpLink)
+	if err := os.Symlink(path.Base(target), tmpLink); err != nil {
+		return "", err
+	}
+
+	var previous string
+	info, err := os.Lstat(catalogRoot)
+	if err == nil && info.Mode()&os.ModeSymlink != 0 {
+		previous, err = filepath.EvalSymlinks(catalogRoot)
+		if err != nil {
+			log.Errorf("Cannot resolve the catalog root %s, error: %v", catalogRoot, err)
+			previous = ""
+		}
+	} else if err == nil {
+		//first refresh of a cloned catalog: the root is still a directory, which cannot be replaced
+		//atomically, so move it aside for the brief moment until the symlink is in place
+		previous = catalogRoot + ".old"
+		if err := os.Rename(catalogRoot, previous); err != nil {
+			os.Remove(tmpLink)
+			return "", err
+		}
+	}
+
+	if err := os.Rename(tmpLink, catalogRoot); err != nil {
+		return "", err
+	}
+	return previous, nil
+}
@@ -0,0 +1,79 @@
+package manager
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/blang/semver"
+)
+
+//checkoutLatestTag fetches the tags of the catalog and checks out the highest one matching -catalogTagPattern
+func (cat *Catalog) checkoutLatestTag() error {
+	e := exec.Command("git", "-C", cat.catalogRoot, "fetch", "--tags", "origin")
+	if err := cat.runGit(e); err != nil {
+		log.Errorf("Failed to fetch the tags of the catalog from git repo %s, error: %v", cat.URL, err)
+		return err
+	}
+
+	e = exec.Command("git", "-C", cat.catalogRoot, "tag", "--list", *catalogTagPattern)
+	out, err := cat.gitOutput(e)
+	if err != nil {
+		log.Errorf("Failed to list the tags of the catalog %s, error: %v", cat.CatalogID, err)
+		return err
+	}
+
+	tag := latestTag(strings.Fields(string(out)))
+	if tag == "" {
+		err := fmt.Errorf("no tag of the catalog %s matches the pattern %s", cat.CatalogID, *catalogTagPattern)
+		log.Error(err)
+		return err
+	}
+
+	log.Debugf("Checking out tag %s of the catalog %s", tag, cat.CatalogID)
+	e = exec.Command("git", "-C", cat.catalogRoot, "checkout", "--quiet", "--force", "refs/tags/"+tag)
+	if err := cat.runGit(e); err != nil {
+		log.Errorf("Failed to check out tag %s of the catalog %s, error: %v", tag, cat.CatalogID, err)
+		return err
+	}
+	return nil
+}
+
+//checkoutInitialTag checks out the latest tag before the first walk of the catalog with -catalogTagPattern, so
+//that the branch head the clone leaves is never served
+func (cat *Catalog) checkoutInitialTag() error {
+	if *catalogTagPattern == "" {
+		return nil
+	}
+	if err := cat.checkoutLatestTag(); err != nil {
+		errorStr := "Failed to check out the latest tag of the catalog: " + err.Error()
+		if cat.metadata != nil {
+			cat.State = "degraded"
+			cat.Message = errorStr + ", serving the templates walked before"
+		} else {
+			cat.State = "error"
+			cat.Message = errorStr
+		}
+		return err
+	}
+	return nil
+}
+
+//latestTag returns the tag with the highest semantic version, tags that are not versions are ignored
+func latestTag(tags []string) string {
+	var latest string
+	var latestVersion *semver.Version
+	for _, tag := range tags {
+		version, err := parseVersion(tag)
+		if err != nil {
+			log.Debugf("Ignoring the tag %s, which is not a version: %v", tag, err)
+			continue
+		}
+		if latestVersion == nil || latestVersion.LT(*version) {
+			latest = tag
+			latestVersion = version
+		}
+	}
+	return latest
+}
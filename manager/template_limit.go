@@ -0,0 +1,26 @@
+package manager
+
+import (
+	"errors"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+//errTemplateLimit stops the walk of a catalog that holds more templates than -maxTemplates
+var errTemplateLimit = errors.New("the catalog holds more templates than the maximum template count")
+
+//checkTemplateLimit warns when the walk is about to load one template more than -maxTemplates, which is likely
+//a catalog pointed at the wrong folder, and returns errTemplateLimit if no more templates are to be loaded
+func (cat *Catalog) checkTemplateLimit() error {
+	if *maxTemplates <= 0 || len(cat.metadata) < *maxTemplates {
+		return nil
+	}
+	if len(cat.metadata) == *maxTemplates {
+		log.Warnf("!!! Catalog %s holds more than the maximum of %d templates, check that its URL points at a catalog repo !!!", cat.CatalogID, *maxTemplates)
+		cat.addDiagnostic(cat.CatalogID, "The catalog holds more than the maximum of %d templates", *maxTemplates)
+	}
+	if *stopAtMaxTemplates {
+		return errTemplateLimit
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package manager
+
+import (
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+//prefixedTemplatesDir matches the name of a templates folder whose templates have ids prefixed like k8s*
+var prefixedTemplatesDir = regexp.MustCompile(`^(\w+)-templates$`)
+
+//templatesDirList holds the folders of -templatesDir in the order they are walked, empty to walk the
+//templates folder and every <prefix>-templates folder
+var templatesDirList []string
+
+//setTemplatesDirs reads the folders of -templatesDir, which are relative to the catalog repo root
+func setTemplatesDirs() {
+	templatesDirList = nil
+	for _, dir := range strings.Split(*templatesDir, ",") {
+		if dir = strings.TrimSpace(dir); dir == "" {
+			continue
+		}
+		dir = path.Clean(filepath.ToSlash(dir))
+		if path.IsAbs(dir) || dir == "." || dir == ".." || strings.HasPrefix(dir, "../") {
+			log.Fatalf("Invalid templates directory %s, it must be a folder of the catalog repo", dir)
+		}
+		templatesDirList = append(templatesDirList, dir)
+	}
+}
+
+//templatesDirPrefix returns the prefix of the ids of the templates of a templates folder, empty if the ids
+//are unprefixed
+func templatesDirPrefix(dir string) string {
+	if match := prefixedTemplatesDir.FindStringSubmatch(path.Base(dir)); match != nil {
+		return match[1]
+	}
+	return ""
+}
+
+//walkTemplatesDirs walks the folders of -templatesDir in order, so that the template of the first folder
+//is served when several folders hold templates with the same id
+func (cat *Catalog) walkTemplatesDirs() error {
+	for _, dir := range templatesDirList {
+		//the trailing separator makes the walk descend into a folder that is a symlink
+		if err := filepath.Walk(path.Join(cat.catalogRoot, dir)+"/", cat.walkCatalog); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//templatesDirOf tells the templates folder, relative to the catalog root, of the template folder at the
+//given forward slash separated path, it returns false if the path is not a template folder
+func (cat *Catalog) templatesDirOf(slashPath string) (string, bool) {
+	if len(templatesDirList) == 0 {
+		if !metadataFolder.MatchString(slashPath) {
+			return "", false
+		}
+		return metadataFolder.ReplaceAllString(slashPath, "$1"), true
+	}
+
+	root := path.Clean(filepath.ToSlash(cat.catalogRoot)) + "/"
+	if !strings.HasPrefix(slashPath, root) {
+		return "", false
+	}
+	dir := path.Dir(strings.TrimPrefix(slashPath, root))
+	for _, templatesDir := range templatesDirList {
+		if dir == templatesDir {
+			return dir, true
+		}
+	}
+	return "", false
+}
+
+//templateFolder returns the templates folder holding the template with the given id and the name of its
+//folder, like ExtractTemplatePrefixAndName does for the catalogs walked without -templatesDir
+func (cat *Catalog) templateFolder(templateID string) (string, string) {
+	prefix, templateName := ExtractTemplatePrefixAndName(templateID)
+	if dir, ok := cat.templateDirs[cat.CatalogID+"/"+templateID]; ok {
+		return dir, templateName
+	}
+	return prefix, templateName
+}
+
+//TemplateFolder returns the templates folder holding the template with the given id in the catalog and
+//the name of its folder
+func TemplateFolder(catalogID string, templateID string) (string, string) {
+	if cat, ok := CatalogsCollection[catalogID]; ok {
+		return cat.templateFolder(templateID)
+	}
+	return ExtractTemplatePrefixAndName(templateID)
+}
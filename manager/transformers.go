@@ -0,0 +1,45 @@
+package manager
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/rancher/rancher-catalog-service/model"
+)
+
+//TemplateTransformer post-processes a template once it is read by the walk of its catalog, and a template version
+//once it is read, for instance to add labels or to rewrite the images to a registry mirror; an error leaves the
+//template or the template version out of the catalog. The maps and slices of a template version may be shared
+//with its template, a transformer changing them replaces them with changed copies
+type TemplateTransformer func(*model.Template) error
+
+type namedTransformer struct {
+	name      string
+	transform TemplateTransformer
+}
+
+var (
+	transformersLock sync.RWMutex
+	//transformers holds the registered template transformers in the order they run
+	transformers []namedTransformer
+)
+
+//RegisterTemplateTransformer adds a transformer run on every template and template version read, after the ones
+//registered before it; it is meant to be called from the init function of a package built into the service
+func RegisterTemplateTransformer(name string, transform TemplateTransformer) {
+	transformersLock.Lock()
+	defer transformersLock.Unlock()
+	transformers = append(transformers, namedTransformer{name: name, transform: transform})
+}
+
+//transformTemplate runs the registered transformers on the template, it returns the error of the first one failing
+func transformTemplate(template *model.Template) error {
+	transformersLock.RLock()
+	defer transformersLock.RUnlock()
+	for _, transformer := range transformers {
+		if err := transformer.transform(template); err != nil {
+			return fmt.Errorf("the template transformer %s failed: %v", transformer.name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package manager
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/rancher/rancher-catalog-service/model"
+)
+
+const testHelmIndex = `apiVersion: v1
+entries:
+  etcd:
+  - name: etcd
+    version: 0.2.0
+    description: etcd chart
+  - name: etcd
+    version: 0.1.0
+    description: etcd chart
+`
+
+//withTransformers runs the test with only the given transformers registered
+func withTransformers(registered []namedTransformer, test func()) {
+	transformersLock.Lock()
+	previous := transformers
+	transformers = registered
+	transformersLock.Unlock()
+	defer func() {
+		transformersLock.Lock()
+		transformers = previous
+		transformersLock.Unlock()
+	}()
+	test()
+}
+
+func readTestHelmCatalog(t *testing.T) *Catalog {
+	dir, err := ioutil.TempDir("", "helm-catalog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path.Join(dir, helmIndexFile), []byte(testHelmIndex), 0644); err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	cat := &Catalog{
+		CatalogID:   "helm",
+		catalogRoot: dir,
+		metadata:    make(map[string]model.Template),
+		diagnostics: make(map[string][]string),
+	}
+	cat.readHelmIndex()
+	return cat
+}
+
+func describe(template *model.Template) error {
+	template.Description = "transformed " + template.Description
+	return nil
+}
+
+func TestTransformHelmTemplates(t *testing.T) {
+	withTransformers([]namedTransformer{{name: "describe", transform: describe}}, func() {
+		cat := readTestHelmCatalog(t)
+		template, ok := cat.metadata["helm/etcd"]
+		if !ok {
+			t.Fatalf("Chart etcd not read: %v", cat.diagnostics)
+		}
+		if template.Description != "transformed etcd chart" {
+			t.Fatalf("Chart not transformed: %q", template.Description)
+		}
+
+		version, ok := cat.ReadTemplateVersion(log.NewEntry(log.StandardLogger()), "etcd", "0.1.0")
+		if !ok {
+			t.Fatal("Chart version 0.1.0 not read")
+		}
+		if version.Description != "transformed etcd chart" {
+			t.Fatalf("Chart version not transformed: %q", version.Description)
+		}
+		//every read transforms the version read from the index once
+		version, _ = cat.ReadTemplateVersion(log.NewEntry(log.StandardLogger()), "etcd", "0.1.0")
+		if version.Description != "transformed etcd chart" {
+			t.Fatalf("Chart version transformed more than once: %q", version.Description)
+		}
+	})
+}
+
+func TestFailingTransformerSkipsHelmTemplates(t *testing.T) {
+	fail := func(template *model.Template) error {
+		return errors.New("rejected")
+	}
+
+	withTransformers([]namedTransformer{{name: "fail", transform: fail}}, func() {
+		cat := readTestHelmCatalog(t)
+		if _, ok := cat.metadata["helm/etcd"]; ok {
+			t.Fatal("Chart etcd read although its transformer failed")
+		}
+		expected := "Skipping the template, the template transformer fail failed: rejected"
+		if diagnostics := cat.diagnostics["helm/etcd"]; len(diagnostics) != 1 || diagnostics[0] != expected {
+			t.Fatalf("Diagnostics incorrect: %v", diagnostics)
+		}
+	})
+
+	cat := readTestHelmCatalog(t)
+	withTransformers([]namedTransformer{{name: "fail", transform: fail}}, func() {
+		if _, ok := cat.ReadTemplateVersion(log.NewEntry(log.StandardLogger()), "etcd", "0.1.0"); ok {
+			t.Fatal("Chart version 0.1.0 read although its transformer failed")
+		}
+	})
+}
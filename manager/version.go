@@ -0,0 +1,4 @@
+package manager
+
+//Version is the version of the service, set at build time
+var Version = "dev"
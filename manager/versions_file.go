@@ -0,0 +1,80 @@
+package manager
+
+import (
+	"errors"
+	"os"
+	"path"
+
+	"github.com/rancher/rancher-catalog-service/model"
+	"gopkg.in/yaml.v2"
+)
+
+//versionsFile lists the version folders of a template in the order they are shown, with their display names
+const versionsFile string = "versions.yml"
+
+//versionsEntry is a version folder listed in versions.yml, either the folder name or a folder and name pair
+type versionsEntry struct {
+	Folder string `yaml:"folder"`
+	Name   string `yaml:"name,omitempty"`
+}
+
+//UnmarshalYAML reads an entry given as the plain folder name too
+func (entry *versionsEntry) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var folder string
+	if err := unmarshal(&folder); err == nil {
+		entry.Folder = folder
+		return nil
+	}
+	type plainEntry versionsEntry
+	return unmarshal((*plainEntry)(entry))
+}
+
+//readVersionsFile reads the versions.yml of the template folder, it returns false if the template has none and
+//true along with the error if it cannot be read
+func readVersionsFile(templatePath string) ([]versionsEntry, bool, error) {
+	content, err := readFileContent(path.Join(templatePath, versionsFile))
+	if os.IsNotExist(err) {
+		return nil, false, nil
+	} else if err != nil {
+		return nil, true, err
+	}
+
+	versions := struct {
+		Versions []versionsEntry `yaml:"versions"`
+	}{}
+	if err := yaml.Unmarshal(normalizeText(content), &versions); err != nil {
+		return nil, true, err
+	}
+	for _, entry := range versions.Versions {
+		if entry.Folder == "" {
+			return nil, true, errors.New("a version is listed without its folder")
+		}
+	}
+	return versions.Versions, true, nil
+}
+
+//orderVersions sets the versions of the template in the order of its versions.yml, or of its version folders
+//if it has none, along with the display names of versions.yml
+func (cat *Catalog) orderVersions(template *model.Template, entries []versionsEntry, hasVersionsFile bool, folders []string, folderVersions map[string]string) {
+	if !hasVersionsFile {
+		for _, folder := range folders {
+			entries = append(entries, versionsEntry{Folder: folder})
+		}
+	}
+	for _, entry := range entries {
+		version, ok := folderVersions[entry.Folder]
+		if !ok {
+			if hasVersionsFile {
+				cat.addDiagnostic(template.Path, "The %s lists the missing version folder: %s", versionsFile, entry.Folder)
+			}
+			continue
+		}
+		template.Versions = append(template.Versions, version)
+		if entry.Name != "" {
+			if template.VersionNames == nil {
+				template.VersionNames = make(map[string]string)
+			}
+			template.VersionNames[version] = entry.Name
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package manager
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+//setBranchWorktrees makes every catalog cloned from the same repo url as another catalog, at another branch, a
+//worktree of the clone of the first of them by catalog id with -branchWorktrees, so that serving several branches
+//of a repo clones it once; catalogs at the same branch as one of the group are cloned on their own
+func setBranchWorktrees(catalogs map[string]*Catalog) {
+	if !*branchWorktrees || *externalCheckout || *snapshot || *catalogTagPattern != "" {
+		return
+	}
+
+	var catalogIDs []string
+	for catalogID := range catalogs {
+		catalogIDs = append(catalogIDs, catalogID)
+	}
+	sort.Strings(catalogIDs)
+
+	primaries := make(map[string]*Catalog)
+	branches := make(map[string]map[string]bool)
+	for _, catalogID := range catalogIDs {
+		cat := catalogs[catalogID]
+		if cat.embedded || cat.fromObjectStorage() || cat.fromZipBundles() {
+			continue
+		}
+		primary, ok := primaries[cat.URL]
+		if !ok {
+			primaries[cat.URL] = cat
+			branches[cat.URL] = map[string]bool{cat.URLBranch: true}
+			continue
+		}
+		if branches[cat.URL][cat.URLBranch] {
+			continue
+		}
+		branches[cat.URL][cat.URLBranch] = true
+		cat.worktreeOf = primary.CatalogID
+		log.Infof("Serving the branch %s of catalog %s from a worktree of the clone of catalog %s", cat.URLBranch, cat.CatalogID, primary.CatalogID)
+	}
+}
+
+//catalogsInCloneOrder lists the catalogs with the worktrees last, so that the clones they are added to come first
+func catalogsInCloneOrder() []*Catalog {
+	var clones, worktrees []*Catalog
+	for _, cat := range CatalogsCollection {
+		if cat.worktreeOf != "" {
+			worktrees = append(worktrees, cat)
+		} else {
+			clones = append(clones, cat)
+		}
+	}
+	return append(clones, worktrees...)
+}
+
+//readWorktree walks the worktree of the catalog, adding it first if it is missing, broken or a clone of its own
+func (cat *Catalog) readWorktree() error {
+	info, err := os.Stat(path.Join(cat.catalogRoot, ".git"))
+	if err != nil || info.IsDir() || !cat.gitRepoHealthy() {
+		return cat.addWorktree()
+	}
+	log.Debugf("Catalog %v already has a worktree of catalog %v, pulling updates", cat.CatalogID, cat.worktreeOf)
+	if err := cat.verifyExistingCommit(); err != nil {
+		return err
+	}
+	return cat.loadInitialMetadata()
+}
+
+//addWorktree checks out the branch of the catalog as a worktree of the clone of its primary catalog, replacing
+//any previous checkout, and walks it
+func (cat *Catalog) addWorktree() error {
+	if err := cat.checkoutWorktree(); err != nil {
+		errorStr := fmt.Sprintf("Failed to add the worktree of branch %s to the clone of catalog %s, error: %v", cat.URLBranch, cat.worktreeOf, err)
+		log.Error(errorStr)
+		//the background poll retries adding the worktree instead of pulling
+		cat.needsClone = true
+		cat.State = "error"
+		cat.Message = errorStr
+		return err
+	}
+	cat.needsClone = false
+
+	if err := cat.loadInitialMetadata(); err != nil {
+		return err
+	}
+	cat.LastUpdated = time.Now().Format(time.RFC3339)
+	cat.State = "active"
+	cat.Message = ""
+	return nil
+}
+
+//checkoutWorktree fetches the branch of the catalog into the clone of its primary catalog and adds the worktree
+func (cat *Catalog) checkoutWorktree() error {
+	primaryRoot := CatalogRootDir + cat.worktreeOf
+	worktreeRoot, err := filepath.Abs(cat.catalogRoot)
+	if err != nil {
+		return err
+	}
+
+	e := exec.Command("git", "-C", primaryRoot, "fetch", "origin", "+refs/heads/"+cat.URLBranch+":refs/remotes/origin/"+cat.URLBranch)
+	if err := runCommand(e); err != nil {
+		return fmt.Errorf("cannot fetch the branch: %v", err)
+	}
+
+	os.RemoveAll(worktreeRoot)
+	e = exec.Command("git", "-C", primaryRoot, "worktree", "prune")
+	if err := runCommand(e); err != nil {
+		return fmt.Errorf("cannot prune the worktrees: %v", err)
+	}
+	e = exec.Command("git", "-C", primaryRoot, "worktree", "add", "--quiet", "--force", "-B", cat.URLBranch, worktreeRoot, "origin/"+cat.URLBranch)
+	if err := runCommand(e); err != nil {
+		return fmt.Errorf("cannot add the worktree: %v", err)
+	}
+
+	e = exec.Command("git", "-C", worktreeRoot, "submodule", "update", "--init", "--recursive")
+	if err := runCommand(e); err != nil {
+		return fmt.Errorf("cannot update the submodules: %v", err)
+	}
+	if *requireSignedCommits {
+		if err := verifyCommit(worktreeRoot); err != nil {
+			return err
+		}
+		cat.verifiedCommit, _ = cat.headCommit()
+	}
+	return nil
+}
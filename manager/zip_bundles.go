@@ -0,0 +1,355 @@
+package manager
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+//zipBundlesScheme prefixes the URL of a catalog read from a directory of zip bundles, such as zipdir:///srv/bundles
+const zipBundlesScheme string = "zipdir://"
+
+//zipBundle identifies the content of a zip bundle extracted to the catalog, a bundle whose size or modification
+//time changed is extracted again
+type zipBundle struct {
+	size    int64
+	modTime time.Time
+}
+
+//fromZipBundles tells if the catalog is extracted from a directory of zip bundles instead of cloned from a git repo
+func (cat *Catalog) fromZipBundles() bool {
+	return strings.HasPrefix(cat.URL, zipBundlesScheme)
+}
+
+//zipBundlesDir returns the directory holding the zip bundles of the catalog
+func (cat *Catalog) zipBundlesDir() string {
+	return strings.TrimPrefix(cat.URL, zipBundlesScheme)
+}
+
+//zipBundleVersion returns the template and the version folder a bundle named <template>-<version>.zip is
+//extracted to, and false if the file is not named so; the version starts at the first dash followed by a digit,
+//so that app-1.0.0-rc1.zip is the version 1.0.0-rc1 of the template app
+func zipBundleVersion(fileName string) (string, string, bool) {
+	if !strings.EqualFold(path.Ext(fileName), ".zip") {
+		return "", "", false
+	}
+	name := strings.TrimSuffix(fileName, path.Ext(fileName))
+	for i := 1; i < len(name)-1; i++ {
+		if name[i] == '-' && name[i+1] >= '0' && name[i+1] <= '9' {
+			return name[:i], name[i+1:], true
+		}
+	}
+	return "", "", false
+}
+
+//zipBundlesTemplatesDir returns the templates folder of the catalog the bundles are extracted to
+func zipBundlesTemplatesDir() string {
+	if len(templatesDirList) > 0 {
+		return templatesDirList[0]
+	}
+	return "templates"
+}
+
+//listZipBundles lists the zip bundles of the directory of the catalog by file name
+func (cat *Catalog) listZipBundles() (map[string]zipBundle, error) {
+	files, err := ioutil.ReadDir(cat.zipBundlesDir())
+	if err != nil {
+		return nil, err
+	}
+	bundles := make(map[string]zipBundle)
+	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+		if _, _, ok := zipBundleVersion(file.Name()); !ok {
+			if strings.EqualFold(path.Ext(file.Name()), ".zip") {
+				log.Warnf("Skipping the zip bundle %s of catalog %s, it is not named <template>-<version>.zip", file.Name(), cat.CatalogID)
+			}
+			continue
+		}
+		bundles[file.Name()] = zipBundle{size: file.Size(), modTime: file.ModTime()}
+	}
+	return bundles, nil
+}
+
+//syncZipBundles extracts the bundles that are new or changed since the bundles last walked to their version
+//folders and removes the version folders of the bundles removed, it tells if any version folder changed; the
+//bundles that failed to extract are dropped from the given bundles, which the caller records once walked
+func (cat *Catalog) syncZipBundles(bundles map[string]zipBundle) (bool, error) {
+	templatesRoot := path.Join(cat.catalogRoot, zipBundlesTemplatesDir())
+	if err := os.MkdirAll(templatesRoot, 0755); err != nil {
+		return false, err
+	}
+
+	changedTemplates := make(map[string]bool)
+	for fileName, bundle := range bundles {
+		if extracted, ok := cat.zipBundles[fileName]; ok && extracted == bundle {
+			continue
+		}
+		templateName, version, _ := zipBundleVersion(fileName)
+		versionRoot := path.Join(templatesRoot, templateName, version)
+		log.Debugf("Extracting the zip bundle %s of catalog %s to %s", fileName, cat.CatalogID, versionRoot)
+		if err := extractZipBundle(path.Join(cat.zipBundlesDir(), fileName), versionRoot); err != nil {
+			//the bundle is extracted again on the next refresh
+			log.Errorf("Failed to extract the zip bundle %s of catalog %s, error: %v", fileName, cat.CatalogID, err)
+			if extracted, ok := cat.zipBundles[fileName]; ok {
+				bundles[fileName] = extracted
+			} else {
+				delete(bundles, fileName)
+			}
+			continue
+		}
+		changedTemplates[templateName] = true
+	}
+	for fileName := range cat.zipBundles {
+		if _, ok := bundles[fileName]; ok {
+			continue
+		}
+		templateName, version, _ := zipBundleVersion(fileName)
+		log.Debugf("The zip bundle %s of catalog %s was removed, removing its version folder", fileName, cat.CatalogID)
+		os.RemoveAll(path.Join(templatesRoot, templateName, version))
+		changedTemplates[templateName] = true
+	}
+
+	for templateName := range changedTemplates {
+		if err := setZipBundleTemplateFiles(path.Join(templatesRoot, templateName), bundles, templateName); err != nil {
+			return false, err
+		}
+	}
+	return len(changedTemplates) > 0, nil
+}
+
+//setZipBundleTemplateFiles copies the config file and the icons at the root of the latest version of the
+//template to the template folder, or writes a config naming the template if the version has none; the template
+//folder is removed once its last bundle is
+func setZipBundleTemplateFiles(templateRoot string, bundles map[string]zipBundle, templateName string) error {
+	var versions []string
+	for fileName := range bundles {
+		if name, version, _ := zipBundleVersion(fileName); name == templateName {
+			versions = append(versions, version)
+		}
+	}
+	if len(versions) == 0 {
+		return os.RemoveAll(templateRoot)
+	}
+	sort.Sort(versionFolders(versions))
+	latestRoot := path.Join(templateRoot, versions[len(versions)-1])
+
+	files, err := ioutil.ReadDir(templateRoot)
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		if !file.IsDir() {
+			os.Remove(path.Join(templateRoot, file.Name()))
+		}
+	}
+
+	files, err = ioutil.ReadDir(latestRoot)
+	if err != nil {
+		return err
+	}
+	hasConfig := false
+	for _, file := range files {
+		name := file.Name()
+		isConfig := false
+		for _, configFile := range templateConfigFiles {
+			isConfig = isConfig || name == configFile
+		}
+		if file.IsDir() || !(isConfig || strings.HasPrefix(name, "catalogIcon")) {
+			continue
+		}
+		hasConfig = hasConfig || isConfig
+		if err := copyFile(path.Join(latestRoot, name), path.Join(templateRoot, name)); err != nil {
+			return err
+		}
+	}
+	if !hasConfig {
+		config := []byte("name: " + strconv.Quote(templateName) + "\n")
+		return ioutil.WriteFile(path.Join(templateRoot, templateConfigFiles[0]), config, 0644)
+	}
+	return nil
+}
+
+//versionFolders sorts version folder names, numerically when both are numbers
+type versionFolders []string
+
+func (v versionFolders) Len() int      { return len(v) }
+func (v versionFolders) Swap(i, j int) { v[i], v[j] = v[j], v[i] }
+func (v versionFolders) Less(i, j int) bool {
+	a, errA := strconv.Atoi(v[i])
+	b, errB := strconv.Atoi(v[j])
+	if errA == nil && errB == nil {
+		return a < b
+	}
+	return v[i] < v[j]
+}
+
+//extractZipBundle extracts a zip bundle to the version folder, replacing its previous content; a bundle whose
+//files all lie under a single folder is extracted from that folder
+func extractZipBundle(zipPath string, versionRoot string) error {
+	reader, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	stage := versionRoot + ".extract"
+	os.RemoveAll(stage)
+	defer os.RemoveAll(stage)
+
+	prefix := commonZipFolder(reader.File)
+	for _, file := range reader.File {
+		name := path.Clean(file.Name)
+		if path.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") {
+			return fmt.Errorf("the entry %s lies outside of the bundle", file.Name)
+		}
+		if name == "." || name+"/" == prefix {
+			continue
+		}
+		name = strings.TrimPrefix(name, prefix)
+		target := filepath.Join(stage, filepath.FromSlash(name))
+		if file.FileInfo().IsDir() {
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := extractZipFile(file, target); err != nil {
+			return err
+		}
+	}
+
+	if err := os.MkdirAll(stage, 0755); err != nil {
+		return err
+	}
+	if err := os.RemoveAll(versionRoot); err != nil {
+		return err
+	}
+	return os.Rename(stage, versionRoot)
+}
+
+//commonZipFolder returns the folder, with a trailing slash, holding every entry of the bundle, empty if the
+//bundle has entries at its root
+func commonZipFolder(files []*zip.File) string {
+	prefix := ""
+	for _, file := range files {
+		name := path.Clean(file.Name)
+		i := strings.Index(name, "/")
+		if i == -1 {
+			if !file.FileInfo().IsDir() {
+				return ""
+			}
+			name += "/"
+			i = len(name) - 1
+		}
+		if prefix == "" {
+			prefix = name[:i+1]
+		} else if prefix != name[:i+1] {
+			return ""
+		}
+	}
+	return prefix
+}
+
+//extractZipFile writes a file of a zip bundle to the target path, refusing a file larger than -maxFileSize
+func extractZipFile(file *zip.File, target string) error {
+	if *maxFileSize > 0 && file.UncompressedSize64 > uint64(*maxFileSize) {
+		return fmt.Errorf("the file %s is larger than %d bytes", file.Name, *maxFileSize)
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	content, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+	output, err := os.Create(target)
+	if err != nil {
+		return err
+	}
+	defer output.Close()
+	_, err = io.Copy(output, content)
+	return err
+}
+
+//copyFile copies the content of a file to the target path
+func copyFile(source string, target string) error {
+	content, err := ioutil.ReadFile(source)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(target, content, 0644)
+}
+
+//readZipBundles extracts the zip bundles of the catalog to DATA and walks them
+func (cat *Catalog) readZipBundles() error {
+	//the bundles are extracted again from scratch, as nothing tells which bundles the copy on disk holds
+	removeCatalogRoot(cat.catalogRoot)
+	cat.zipBundles = nil
+	bundles, err := cat.listZipBundles()
+	if err == nil {
+		_, err = cat.syncZipBundles(bundles)
+	}
+	if err != nil {
+		errorStr := fmt.Sprintf("Failed to read the zip bundles of the catalog %s from %s, error: %v", cat.CatalogID, cat.zipBundlesDir(), err)
+		log.Error(errorStr)
+		cat.State = "error"
+		cat.Message = errorStr
+		return err
+	}
+
+	cat.zipBundles = bundles
+	log.Infof("Extracted the %d zip bundles of the catalog %s from %s", len(cat.zipBundles), cat.CatalogID, cat.zipBundlesDir())
+	if err := cat.loadInitialMetadata(); err != nil {
+		return err
+	}
+	cat.LastUpdated = time.Now().Format(time.RFC3339)
+	cat.State = "active"
+	cat.Message = ""
+	return nil
+}
+
+//refreshZipBundles extracts the zip bundles added or changed since the last refresh, as told by their size and
+//modification time, and walks the catalog again if any bundle was extracted or removed
+func (cat *Catalog) refreshZipBundles() error {
+	bundles, err := cat.listZipBundles()
+	if err != nil {
+		log.Errorf("Cannot list the zip bundles of the catalog %s at %s, error: %v", cat.CatalogID, cat.zipBundlesDir(), err)
+		return err
+	}
+	changed, err := cat.syncZipBundles(bundles)
+	if err != nil {
+		return err
+	}
+	if !changed {
+		cat.zipBundles = bundles
+		log.Debugf("The zip bundles of the catalog %s are unchanged since the last refresh", cat.CatalogID)
+		return nil
+	}
+
+	setRefreshState(cat.CatalogID, refreshStateWalking)
+	//walk into a copy, so that the previous metadata is kept if the walk is aborted
+	staged := *cat
+	if err := staged.loadMetadata(); err != nil {
+		return err
+	}
+	cat.adoptMetadata(&staged)
+	//the bundles are recorded once walked, so that a walk aborted is run again by the next refresh
+	cat.zipBundles = bundles
+	cat.LastUpdated = time.Now().Format(time.RFC3339)
+	cat.State = "active"
+	cat.Message = ""
+	return nil
+}
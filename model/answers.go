@@ -0,0 +1,115 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/rancher/go-rancher/client"
+)
+
+//AnswersValidation structure holds the result of validating answers against the questions of a template version
+type AnswersValidation struct {
+	client.Resource
+	Valid  bool     `json:"valid"`
+	Errors []string `json:"errors"`
+}
+
+//ValidateAnswers checks the given answers against the validation constraints of the questions and returns a
+//message per violation, including the required questions left without an answer or a default; the other
+//questions left unanswered are not checked and the messages about secret questions never quote the answer
+func ValidateAnswers(questions []Question, answers map[string]string) []string {
+	errors := append(MissingAnswers(questions, AnswerValues(questions, answers)), ValidateAnswerConstraints(questions, answers)...)
+	sort.Strings(errors)
+	return errors
+}
+
+//ValidateAnswerConstraints checks the given answers against the validation constraints of the questions and
+//returns a message per violation, the questions left unanswered are not checked, required or not
+func ValidateAnswerConstraints(questions []Question, answers map[string]string) []string {
+	errors := []string{}
+	for _, question := range questions {
+		answer, ok := answers[question.Variable]
+		if !ok || answer == "" {
+			continue
+		}
+		errors = append(errors, validateAnswer(question, answer)...)
+	}
+	sort.Strings(errors)
+	return errors
+}
+
+func validateAnswer(question Question, answer string) []string {
+	var errors []string
+
+	switch question.Type {
+	case "boolean":
+		if answer != "true" && answer != "false" {
+			if question.Secret {
+				return []string{fmt.Sprintf("%s: the answer is not true or false", question.Variable)}
+			}
+			return []string{fmt.Sprintf("%s: %q is not true or false", question.Variable, answer)}
+		}
+	case "enum":
+		if len(question.Options) > 0 && !hasOption(question.Options, answer) {
+			if question.Secret {
+				return []string{fmt.Sprintf("%s: the answer is not one of the options", question.Variable)}
+			}
+			return []string{fmt.Sprintf("%s: %q is not one of the options %s", question.Variable, answer, strings.Join(question.Options, ", "))}
+		}
+	}
+
+	if question.Type == "int" {
+		value, err := strconv.Atoi(answer)
+		if err != nil {
+			if question.Secret {
+				return []string{fmt.Sprintf("%s: the answer is not an integer", question.Variable)}
+			}
+			return []string{fmt.Sprintf("%s: %q is not an integer", question.Variable, answer)}
+		}
+		quoted := strconv.Itoa(value)
+		if question.Secret {
+			quoted = "the answer"
+		}
+		if min, ok := question.MinValue(); ok && value < min {
+			errors = append(errors, fmt.Sprintf("%s: %s is less than the minimum of %d", question.Variable, quoted, min))
+		}
+		if max, ok := question.MaxValue(); ok && value > max {
+			errors = append(errors, fmt.Sprintf("%s: %s is greater than the maximum of %d", question.Variable, quoted, max))
+		}
+	}
+
+	length := len([]rune(answer))
+	if question.MinLength != 0 && length < question.MinLength {
+		errors = append(errors, fmt.Sprintf("%s: length %d is less than the minimum length of %d", question.Variable, length, question.MinLength))
+	}
+	if question.MaxLength != 0 && length > question.MaxLength {
+		errors = append(errors, fmt.Sprintf("%s: length %d is greater than the maximum length of %d", question.Variable, length, question.MaxLength))
+	}
+
+	for _, char := range answer {
+		quoted := strconv.QuoteRune(char)
+		if question.Secret {
+			quoted = "of the answer"
+		}
+		if question.ValidChars != "" && !strings.ContainsRune(question.ValidChars, char) {
+			errors = append(errors, fmt.Sprintf("%s: character %s is not one of the valid characters", question.Variable, quoted))
+			break
+		}
+		if question.InvalidChars != "" && strings.ContainsRune(question.InvalidChars, char) {
+			errors = append(errors, fmt.Sprintf("%s: character %s is not allowed", question.Variable, quoted))
+			break
+		}
+	}
+	return errors
+}
+
+func hasOption(options []string, answer string) bool {
+	for _, option := range options {
+		if option == answer {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,213 @@
+package model
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+func TestValidateAnswers(t *testing.T) {
+	questions := []Question{
+		{
+			Variable: "PORT",
+			Type:     "int",
+			Min:      1024,
+			Max:      65535,
+		},
+		{
+			Variable:  "NAME",
+			Type:      "string",
+			MinLength: 2,
+			MaxLength: 8,
+		},
+		{
+			Variable:     "TAG",
+			Type:         "string",
+			ValidChars:   "abc123",
+			InvalidChars: "3",
+		},
+	}
+
+	errors := ValidateAnswers(questions, map[string]string{
+		"PORT": "80",
+		"NAME": "n",
+		"TAG":  "ab3",
+	})
+	expected := []string{
+		"NAME: length 1 is less than the minimum length of 2",
+		"PORT: 80 is less than the minimum of 1024",
+		"TAG: character '3' is not allowed",
+	}
+	if !reflect.DeepEqual(errors, expected) {
+		t.Fatalf("Validation errors incorrect: %v", errors)
+	}
+
+	errors = ValidateAnswers(questions, map[string]string{
+		"PORT": "port",
+		"TAG":  "abx",
+	})
+	expected = []string{
+		"PORT: \"port\" is not an integer",
+		"TAG: character 'x' is not one of the valid characters",
+	}
+	if !reflect.DeepEqual(errors, expected) {
+		t.Fatalf("Validation errors incorrect: %v", errors)
+	}
+
+	errors = ValidateAnswers(questions, map[string]string{
+		"PORT": "8080",
+		"NAME": "redis",
+	})
+	if len(errors) != 0 {
+		t.Fatalf("Valid answers reported errors: %v", errors)
+	}
+}
+
+func TestValidateAnswersTypesAndRequired(t *testing.T) {
+	questions := []Question{
+		{Variable: "DEBUG", Type: "boolean"},
+		{Variable: "MODE", Type: "enum", Options: []string{"primary", "replica"}},
+		{Variable: "NAME", Type: "string", Required: true},
+		{Variable: "PORT", Type: "int", Required: true, Default: "6379"},
+	}
+
+	errors := ValidateAnswers(questions, map[string]string{
+		"DEBUG": "yes",
+		"MODE":  "standalone",
+	})
+	expected := []string{
+		"DEBUG: \"yes\" is not true or false",
+		"MODE: \"standalone\" is not one of the options primary, replica",
+		"NAME: an answer is required",
+	}
+	if !reflect.DeepEqual(errors, expected) {
+		t.Fatalf("Validation errors incorrect: %v", errors)
+	}
+
+	errors = ValidateAnswers(questions, map[string]string{
+		"DEBUG": "false",
+		"MODE":  "replica",
+		"NAME":  "cache",
+	})
+	if len(errors) != 0 {
+		t.Fatalf("Valid answers reported errors: %v", errors)
+	}
+
+	errors = ValidateAnswerConstraints(questions, map[string]string{"DEBUG": "yes"})
+	if expected := []string{"DEBUG: \"yes\" is not true or false"}; !reflect.DeepEqual(errors, expected) {
+		t.Fatalf("Constraint errors incorrect, the required questions must not be checked: %v", errors)
+	}
+}
+
+func TestUnmarshalQuestionConstraints(t *testing.T) {
+	content := []byte(`
+- variable: NAME
+  min_length: 2
+  maxLength: 8
+  validChars: abc
+  invalid_chars: "-"
+`)
+	var questions []Question
+	if err := yaml.Unmarshal(content, &questions); err != nil {
+		t.Fatal(err)
+	}
+
+	question := questions[0]
+	if question.Variable != "NAME" || question.MinLength != 2 || question.MaxLength != 8 ||
+		question.ValidChars != "abc" || question.InvalidChars != "-" {
+		t.Fatalf("Question constraints incorrect: %+v", question)
+	}
+}
+
+func TestValidateZeroMinAndMax(t *testing.T) {
+	content := []byte(`
+- variable: OFFSET
+  type: int
+  min: 0
+- variable: DELTA
+  type: int
+  max: 0
+- variable: COUNT
+  type: int
+`)
+	var questions []Question
+	if err := yaml.Unmarshal(content, &questions); err != nil {
+		t.Fatal(err)
+	}
+
+	errors := ValidateAnswers(questions, map[string]string{
+		"OFFSET": "-1",
+		"DELTA":  "1",
+		"COUNT":  "-1",
+	})
+	expected := []string{
+		"DELTA: 1 is greater than the maximum of 0",
+		"OFFSET: -1 is less than the minimum of 0",
+	}
+	if !reflect.DeepEqual(errors, expected) {
+		t.Fatalf("Zero bound errors incorrect, expected %v, got %v", expected, errors)
+	}
+}
+
+func TestUnmarshalQuestionGroup(t *testing.T) {
+	content := []byte(`
+- variable: NAME
+  group: General
+- variable: PORT
+  section: Network
+- variable: DEBUG
+`)
+	var questions []Question
+	if err := yaml.Unmarshal(content, &questions); err != nil {
+		t.Fatal(err)
+	}
+
+	if questions[0].Group != "General" || questions[1].Group != "Network" || questions[2].Group != "" {
+		t.Fatalf("Question groups incorrect: %+v", questions)
+	}
+}
+
+func TestUnmarshalSecretQuestions(t *testing.T) {
+	content := []byte(`
+- variable: PASSWORD
+  type: secret
+- variable: TOKEN
+  type: string
+  sensitive: true
+- variable: KEY
+  secret: true
+- variable: NAME
+`)
+	var questions []Question
+	if err := yaml.Unmarshal(content, &questions); err != nil {
+		t.Fatal(err)
+	}
+
+	if !questions[0].Secret || !questions[1].Secret || !questions[2].Secret || questions[3].Secret {
+		t.Fatalf("Secret questions incorrect: %+v", questions)
+	}
+}
+
+func TestValidateSecretAnswersNotQuoted(t *testing.T) {
+	errors := ValidateAnswers([]Question{
+		{Variable: "PIN", Type: "int", Secret: true},
+		{Variable: "TOKEN", ValidChars: "abc", Secret: true},
+		{Variable: "LOW_PIN", Type: "int", Min: 1000, Secret: true},
+		{Variable: "HIGH_PIN", Type: "int", Max: 1000, Secret: true},
+	}, map[string]string{
+		"PIN":      "s3cret",
+		"TOKEN":    "s3cret",
+		"LOW_PIN":  "42",
+		"HIGH_PIN": "4242",
+	})
+	if len(errors) != 4 {
+		t.Fatalf("Expected 4 errors, got %v", errors)
+	}
+	for _, message := range errors {
+		if strings.Contains(message, "s3cret") || strings.Contains(message, "'s'") || strings.Contains(message, "42 ") {
+			t.Fatalf("Error quotes the secret answer: %s", message)
+		}
+	}
+}
@@ -27,10 +27,14 @@ type ServiceBinding struct {
 
 //CreateBindings creates bindings property
 func CreateBindings(pathToYml string) (BindingProperty, error) {
+	return CreateBindingsFromFile(pathToYml + "/docker-compose.yml")
+}
+
+//CreateBindingsFromFile creates bindings property from the given docker-compose file, empty if it does not exist
+func CreateBindingsFromFile(dockerFile string) (BindingProperty, error) {
 
 	var bindingPropertyMap BindingProperty
 
-	dockerFile := pathToYml + "/docker-compose.yml"
 	_, err := os.Stat(dockerFile)
 	if os.IsNotExist(err) {
 		return BindingProperty{}, nil
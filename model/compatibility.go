@@ -0,0 +1,23 @@
+package model
+
+import "github.com/rancher/go-rancher/client"
+
+//TemplateCompatibility structure holds the constraints declared by every version of a template
+type TemplateCompatibility struct {
+	client.Resource
+	TemplateID    string                 `json:"templateId"`
+	Orchestration string                 `json:"orchestration,omitempty"`
+	Dependencies  []string               `json:"dependencies,omitempty"`
+	Versions      []VersionCompatibility `json:"versions"`
+}
+
+//VersionCompatibility structure holds the constraints declared by a template version
+type VersionCompatibility struct {
+	Version               string `json:"version"`
+	TemplateVersionID     string `json:"templateVersionId"`
+	MinimumRancherVersion string `json:"minimumRancherVersion,omitempty"`
+	MaximumRancherVersion string `json:"maximumRancherVersion,omitempty"`
+	UpgradeFrom           string `json:"upgradeFrom,omitempty"`
+	Stage                 string `json:"stage,omitempty"`
+	Yanked                bool   `json:"yanked,omitempty"`
+}
@@ -0,0 +1,27 @@
+package model
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v2"
+)
+
+//ComposeFormatV1 is the version of a docker-compose file without a version key, the services at its top level
+const ComposeFormatV1 = "1"
+
+//ComposeVersion returns the format version of a docker-compose file, and whether its syntax is deprecated, which is
+//the case of the version 1 format: removed from docker-compose, it lacks networks, volumes and the other keys of
+//the later versions
+func ComposeVersion(yamlContent []byte) (string, bool, error) {
+	var content map[string]interface{}
+	if err := yaml.Unmarshal(yamlContent, &content); err != nil {
+		return "", false, err
+	}
+
+	version, ok := content["version"]
+	if !ok || version == nil {
+		return ComposeFormatV1, true, nil
+	}
+	composeVersion := fmt.Sprint(version)
+	return composeVersion, composeVersion == ComposeFormatV1, nil
+}
@@ -0,0 +1,31 @@
+package model
+
+import "testing"
+
+func TestComposeVersion(t *testing.T) {
+	tests := []struct {
+		content    string
+		version    string
+		deprecated bool
+	}{
+		{"web:\n  image: nginx\n", "1", true},
+		{"version: '2'\nservices:\n  web:\n    image: nginx\n", "2", false},
+		{"version: 2.1\nservices:\n  web:\n    image: nginx\n", "2.1", false},
+		{"version: \"3\"\nservices: {}\n", "3", false},
+		{"version: 1\nweb:\n  image: nginx\n", "1", true},
+		{"", "1", true},
+	}
+	for _, test := range tests {
+		version, deprecated, err := ComposeVersion([]byte(test.content))
+		if err != nil {
+			t.Fatalf("Unexpected error for %q: %v", test.content, err)
+		}
+		if version != test.version || deprecated != test.deprecated {
+			t.Fatalf("Expected version %s deprecated %v for %q, got %s %v", test.version, test.deprecated, test.content, version, deprecated)
+		}
+	}
+
+	if _, _, err := ComposeVersion([]byte("web: [")); err == nil {
+		t.Fatal("Expected an error for a malformed file")
+	}
+}
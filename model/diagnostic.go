@@ -0,0 +1,17 @@
+package model
+
+import "github.com/rancher/go-rancher/client"
+
+//TemplateDiagnostic structure holds the problems found while loading a template
+type TemplateDiagnostic struct {
+	client.Resource
+	CatalogID    string   `json:"catalogId"`
+	TemplatePath string   `json:"templatePath"`
+	Issues       []string `json:"issues"`
+}
+
+//TemplateDiagnosticCollection holds a collection of template diagnostics
+type TemplateDiagnosticCollection struct {
+	client.Collection
+	Data []TemplateDiagnostic `json:"data,omitempty"`
+}
@@ -0,0 +1,154 @@
+package model
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/rancher/go-rancher/client"
+)
+
+//diffContext is the number of unchanged lines shown around the changes of a unified diff
+const diffContext int = 3
+
+//TemplateDiff structure holds the unified diff of the compose files of two versions of a template
+type TemplateDiff struct {
+	client.Resource
+	TemplateID   string   `json:"templateId"`
+	From         string   `json:"from"`
+	To           string   `json:"to"`
+	ChangedFiles []string `json:"changedFiles"`
+	Diff         string   `json:"diff"`
+}
+
+//diffLine is a line of an edit script, kind is one of ' ', '-' and '+'
+type diffLine struct {
+	kind byte
+	text string
+}
+
+//UnifiedDiff returns the unified diff turning the content from into the content to, empty if they are equal,
+//a missing file is given as the empty content and named /dev/null
+func UnifiedDiff(fromName string, toName string, from string, to string) string {
+	if from == to {
+		return ""
+	}
+	script := editScript(splitLines(from), splitLines(to))
+
+	var diff bytes.Buffer
+	fmt.Fprintf(&diff, "--- %s\n+++ %s\n", fromName, toName)
+	for start := 0; start < len(script); {
+		//find the next change and the end of its hunk, changes closer than twice the context share a hunk
+		first := start
+		for first < len(script) && script[first].kind == ' ' {
+			first++
+		}
+		if first == len(script) {
+			break
+		}
+		last := first
+		for i := first; i < len(script) && i <= last+2*diffContext; i++ {
+			if script[i].kind != ' ' {
+				last = i
+			}
+		}
+		hunkStart := first - diffContext
+		if hunkStart < start {
+			hunkStart = start
+		}
+		hunkEnd := last + diffContext + 1
+		if hunkEnd > len(script) {
+			hunkEnd = len(script)
+		}
+		writeHunk(&diff, script, hunkStart, hunkEnd)
+		start = hunkEnd
+	}
+	return diff.String()
+}
+
+//writeHunk writes the lines of the edit script between start and end as a hunk
+func writeHunk(diff *bytes.Buffer, script []diffLine, start int, end int) {
+	fromLine, toLine := 1, 1
+	for _, line := range script[:start] {
+		if line.kind != '+' {
+			fromLine++
+		}
+		if line.kind != '-' {
+			toLine++
+		}
+	}
+	fromCount, toCount := 0, 0
+	for _, line := range script[start:end] {
+		if line.kind != '+' {
+			fromCount++
+		}
+		if line.kind != '-' {
+			toCount++
+		}
+	}
+	//an empty range starts at the line before it
+	if fromCount == 0 {
+		fromLine--
+	}
+	if toCount == 0 {
+		toLine--
+	}
+	fmt.Fprintf(diff, "@@ -%d,%d +%d,%d @@\n", fromLine, fromCount, toLine, toCount)
+	for _, line := range script[start:end] {
+		diff.WriteByte(line.kind)
+		diff.WriteString(line.text)
+		diff.WriteByte('\n')
+	}
+}
+
+//splitLines splits the content in lines, without the line ends
+func splitLines(content string) []string {
+	if content == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+}
+
+//editScript returns the lines kept, removed and added to turn the lines from into the lines to, along a
+//longest common subsequence
+func editScript(from []string, to []string) []diffLine {
+	//common[i][j] is the length of the longest common subsequence of from[i:] and to[j:]
+	common := make([][]int, len(from)+1)
+	for i := range common {
+		common[i] = make([]int, len(to)+1)
+	}
+	for i := len(from) - 1; i >= 0; i-- {
+		for j := len(to) - 1; j >= 0; j-- {
+			if from[i] == to[j] {
+				common[i][j] = common[i+1][j+1] + 1
+			} else if common[i+1][j] >= common[i][j+1] {
+				common[i][j] = common[i+1][j]
+			} else {
+				common[i][j] = common[i][j+1]
+			}
+		}
+	}
+
+	var script []diffLine
+	i, j := 0, 0
+	for i < len(from) && j < len(to) {
+		if from[i] == to[j] {
+			script = append(script, diffLine{' ', from[i]})
+			i++
+			j++
+		} else if common[i+1][j] >= common[i][j+1] {
+			script = append(script, diffLine{'-', from[i]})
+			i++
+		} else {
+			script = append(script, diffLine{'+', to[j]})
+			j++
+		}
+	}
+	for ; i < len(from); i++ {
+		script = append(script, diffLine{'-', from[i]})
+	}
+	for ; j < len(to); j++ {
+		script = append(script, diffLine{'+', to[j]})
+	}
+	return script
+}
@@ -0,0 +1,35 @@
+package model
+
+import "testing"
+
+func TestUnifiedDiff(t *testing.T) {
+	from := "version: '2'\nservices:\n  redis:\n    image: redis:3.0\n    ports:\n    - 6379\n"
+	to := "version: '2'\nservices:\n  redis:\n    image: redis:3.2\n    ports:\n    - 6379\n    restart: always\n"
+	expected := "--- a/docker-compose.yml\n+++ b/docker-compose.yml\n" +
+		"@@ -1,6 +1,7 @@\n" +
+		" version: '2'\n services:\n   redis:\n-    image: redis:3.0\n+    image: redis:3.2\n     ports:\n     - 6379\n+    restart: always\n"
+	if diff := UnifiedDiff("a/docker-compose.yml", "b/docker-compose.yml", from, to); diff != expected {
+		t.Fatalf("Expected %q, got %q", expected, diff)
+	}
+}
+
+func TestUnifiedDiffHunks(t *testing.T) {
+	from := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n"
+	to := "0\n1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n"
+	expected := "--- a\n+++ b\n" +
+		"@@ -1,3 +1,4 @@\n+0\n 1\n 2\n 3\n" +
+		"@@ -9,4 +10,3 @@\n 9\n 10\n 11\n-12\n"
+	if diff := UnifiedDiff("a", "b", from, to); diff != expected {
+		t.Fatalf("Expected %q, got %q", expected, diff)
+	}
+}
+
+func TestUnifiedDiffNewFile(t *testing.T) {
+	expected := "--- /dev/null\n+++ b/notes.txt\n@@ -0,0 +1,1 @@\n+hello\n"
+	if diff := UnifiedDiff("/dev/null", "b/notes.txt", "", "hello\n"); diff != expected {
+		t.Fatalf("Expected %q, got %q", expected, diff)
+	}
+	if diff := UnifiedDiff("a", "b", "same\n", "same\n"); diff != "" {
+		t.Fatalf("Expected no diff, got %q", diff)
+	}
+}
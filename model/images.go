@@ -0,0 +1,141 @@
+package model
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+//ExtractImages lists the distinct images the services of a docker-compose file, of any format version, run
+func ExtractImages(yamlContent []byte) ([]string, error) {
+	version, _, err := ComposeVersion(yamlContent)
+	if err != nil {
+		return nil, err
+	}
+	var content map[string]interface{}
+	if err := yaml.Unmarshal(yamlContent, &content); err != nil {
+		return nil, err
+	}
+	//the services are at the top level of the version 1 format and under the services key of the later versions
+	services := content
+	if version != ComposeFormatV1 {
+		services = make(map[string]interface{})
+		nested, _ := content["services"].(map[interface{}]interface{})
+		for name, service := range nested {
+			services[fmt.Sprint(name)] = service
+		}
+	}
+
+	seen := make(map[string]bool)
+	images := []string{}
+	for _, service := range services {
+		fields, _ := service.(map[interface{}]interface{})
+		image, _ := fields["image"].(string)
+		if image == "" || seen[image] {
+			continue
+		}
+		seen[image] = true
+		images = append(images, image)
+	}
+	sort.Strings(images)
+	return images, nil
+}
+
+//defaultRegistry is the registry of the images whose name does not start with a registry host, such as nginx
+const defaultRegistry = "docker.io"
+
+//composeImage matches a line of a compose file holding an image key, keeping the key and the quotes around the image
+var composeImage = regexp.MustCompile(`^(\s*image:\s*["']?)([^"'\s#]+)`)
+
+//NormalizeImage returns the image with its registry host, docker.io/library/nginx:1.11 for nginx:1.11
+func NormalizeImage(image string) string {
+	if !strings.Contains(image, "/") {
+		return defaultRegistry + "/library/" + image
+	}
+	return NormalizeRegistryPrefix(image)
+}
+
+//NormalizeRegistryPrefix returns a registry prefix, such as quay.io/coreos or rancher, with its registry host,
+//docker.io/rancher for rancher
+func NormalizeRegistryPrefix(prefix string) string {
+	host := strings.SplitN(prefix, "/", 2)[0]
+	if host == defaultRegistry || host == "index.docker.io" {
+		return defaultRegistry + prefix[len(host):]
+	}
+	if strings.ContainsAny(host, ".:") || host == "localhost" {
+		return prefix
+	}
+	return defaultRegistry + "/" + prefix
+}
+
+//RewriteImageRegistry points the image at the mirror of the longest registry prefix of the rewrites it starts
+//with, such as docker.io or quay.io/coreos, and returns it unchanged if none matches or it holds a placeholder
+func RewriteImageRegistry(image string, rewrites map[string]string) string {
+	if len(rewrites) == 0 || strings.ContainsAny(image, "${}") {
+		return image
+	}
+	normalized := NormalizeImage(image)
+	prefix := ""
+	for from := range rewrites {
+		if len(from) > len(prefix) && (normalized == from || strings.HasPrefix(normalized, from+"/")) {
+			prefix = from
+		}
+	}
+	if prefix == "" {
+		return image
+	}
+	return rewrites[prefix] + normalized[len(prefix):]
+}
+
+//RewriteComposeImages rewrites the registries of the images the services of a docker-compose file run, leaving
+//the rest of the file as written, such as a label or an environment variable named image
+func RewriteComposeImages(content string, rewrites map[string]string) string {
+	if len(rewrites) == 0 {
+		return content
+	}
+	version, _, err := ComposeVersion([]byte(content))
+	if err != nil {
+		return content
+	}
+	//the services are at the top level of the version 1 format and under the services key of the later versions
+	servicesDepth := 2
+	if version == ComposeFormatV1 {
+		servicesDepth = 1
+	}
+
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		match := composeImage.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		keys := parentKeys(lines, i)
+		if len(keys) != servicesDepth || (servicesDepth == 2 && keys[0] != "services") {
+			continue
+		}
+		lines[i] = match[1] + RewriteImageRegistry(match[2], rewrites) + line[len(match[0]):]
+	}
+	return strings.Join(lines, "\n")
+}
+
+//parentKeys returns the keys of the mappings a line of a yaml file is nested in, from the top level one
+func parentKeys(lines []string, index int) []string {
+	indent := indentation(lines[index])
+	var keys []string
+	for i := index - 1; i >= 0 && indent > 0; i-- {
+		trimmed := strings.TrimSpace(lines[i])
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") || indentation(lines[i]) >= indent {
+			continue
+		}
+		indent = indentation(lines[i])
+		keys = append([]string{strings.SplitN(trimmed, ":", 2)[0]}, keys...)
+	}
+	return keys
+}
+
+func indentation(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " "))
+}
@@ -0,0 +1,144 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtractImagesV1(t *testing.T) {
+	images, err := ExtractImages([]byte(`
+web:
+  image: nginx:1.11
+  links:
+  - db
+db:
+  image: mysql:5.7
+cache:
+  image: nginx:1.11
+build_only:
+  build: .`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"mysql:5.7", "nginx:1.11"}; !reflect.DeepEqual(images, expected) {
+		t.Fatalf("Expected images %v, got %v", expected, images)
+	}
+}
+
+func TestExtractImagesV2(t *testing.T) {
+	images, err := ExtractImages([]byte(`
+version: '2'
+services:
+  redis:
+    image: redis:3.2
+  sidekick:
+    image: busybox`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"busybox", "redis:3.2"}; !reflect.DeepEqual(images, expected) {
+		t.Fatalf("Expected images %v, got %v", expected, images)
+	}
+}
+
+func TestExtractImagesV21AndV3(t *testing.T) {
+	for version, content := range map[string]string{
+		"2.1": `
+version: '2.1'
+services:
+  redis:
+    image: redis:3.2
+    healthcheck:
+      test: ["CMD", "redis-cli", "ping"]
+  sidekick:
+    image: busybox`,
+		"3": `
+version: "3"
+services:
+  redis:
+    image: redis:3.2
+    deploy:
+      replicas: 2
+  sidekick:
+    image: busybox
+networks:
+  default:
+    image: not-a-service`,
+	} {
+		images, err := ExtractImages([]byte(content))
+		if err != nil {
+			t.Fatalf("Version %s: %v", version, err)
+		}
+		if expected := []string{"busybox", "redis:3.2"}; !reflect.DeepEqual(images, expected) {
+			t.Fatalf("Version %s: expected images %v, got %v", version, expected, images)
+		}
+	}
+}
+
+func TestRewriteImageRegistry(t *testing.T) {
+	rewrites := map[string]string{
+		"docker.io":           "mirror.internal",
+		"quay.io/coreos":      "mirror.internal/coreos",
+		"quay.io":             "quay-mirror.internal",
+		"registry.local:5000": "mirror.internal:5000",
+	}
+	for image, expected := range map[string]string{
+		"nginx:1.11":                    "mirror.internal/library/nginx:1.11",
+		"rancher/server":                "mirror.internal/rancher/server",
+		"docker.io/rancher/agent:v1":    "mirror.internal/rancher/agent:v1",
+		"index.docker.io/library/redis": "mirror.internal/library/redis",
+		"quay.io/coreos/etcd:v3":        "mirror.internal/coreos/etcd:v3",
+		"quay.io/prometheus/node":       "quay-mirror.internal/prometheus/node",
+		"quay.io/coreosx/etcd":          "quay-mirror.internal/coreosx/etcd",
+		"registry.local:5000/app":       "mirror.internal:5000/app",
+		"gcr.io/google/pause":           "gcr.io/google/pause",
+		"${REGISTRY}/app":               "${REGISTRY}/app",
+	} {
+		if rewritten := RewriteImageRegistry(image, rewrites); rewritten != expected {
+			t.Errorf("Expected %s to be rewritten to %s, got %s", image, expected, rewritten)
+		}
+	}
+}
+
+func TestRewriteComposeImages(t *testing.T) {
+	rewrites := map[string]string{"docker.io": "mirror.internal", "quay.io": "quay-mirror.internal", "gcr.io": "gcr-mirror.internal"}
+	rewritten := RewriteComposeImages(`version: '2'
+services:
+  web:
+    image: "nginx:1.11" # the web server
+    labels:
+      image: gcr.io/google/pause
+    command: |
+      image: gcr.io/google/pause
+  db:
+    image: 'quay.io/coreos/etcd'
+`, rewrites)
+	expected := `version: '2'
+services:
+  web:
+    image: "mirror.internal/library/nginx:1.11" # the web server
+    labels:
+      image: gcr.io/google/pause
+    command: |
+      image: gcr.io/google/pause
+  db:
+    image: 'quay-mirror.internal/coreos/etcd'
+`
+	if rewritten != expected {
+		t.Fatalf("Expected the compose file\n%s\ngot\n%s", expected, rewritten)
+	}
+
+	rewritten = RewriteComposeImages(`web:
+  image: gcr.io/google/pause
+  environment:
+    image: nginx
+`, rewrites)
+	expected = `web:
+  image: gcr-mirror.internal/google/pause
+  environment:
+    image: nginx
+`
+	if rewritten != expected {
+		t.Fatalf("Expected the version 1 compose file\n%s\ngot\n%s", expected, rewritten)
+	}
+}
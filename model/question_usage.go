@@ -0,0 +1,75 @@
+package model
+
+import (
+	"sort"
+
+	"github.com/rancher/go-rancher/client"
+)
+
+//QuestionUsage structure holds a question variable asked by templates, the types it is asked with and the
+//templates asking it
+type QuestionUsage struct {
+	client.Resource
+	Variable  string   `json:"variable"`
+	Types     []string `json:"types"`
+	Templates []string `json:"templates"`
+}
+
+//QuestionUsageCollection holds a collection of question usages
+type QuestionUsageCollection struct {
+	client.Collection
+	Data []QuestionUsage `json:"data,omitempty"`
+}
+
+//QuestionUsages lists the distinct variables of the questions of the templates, by template id, sorted by
+//variable, a question without a type being a string question as rancher-compose asks it
+func QuestionUsages(questionsByTemplate map[string][]Question) []QuestionUsage {
+	types := make(map[string]map[string]bool)
+	templates := make(map[string]map[string]bool)
+	for templateID, questions := range questionsByTemplate {
+		for _, question := range questions {
+			if question.Variable == "" {
+				continue
+			}
+			if types[question.Variable] == nil {
+				types[question.Variable] = make(map[string]bool)
+				templates[question.Variable] = make(map[string]bool)
+			}
+			questionType := question.Type
+			if questionType == "" {
+				questionType = "string"
+			}
+			types[question.Variable][questionType] = true
+			templates[question.Variable][templateID] = true
+		}
+	}
+
+	usages := []QuestionUsage{}
+	for variable := range types {
+		usages = append(usages, QuestionUsage{
+			Resource: client.Resource{
+				Type: "questionUsage",
+			},
+			Variable:  variable,
+			Types:     sortedKeys(types[variable]),
+			Templates: sortedKeys(templates[variable]),
+		})
+	}
+	sort.Sort(questionUsagesByVariable(usages))
+	return usages
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := []string{}
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+type questionUsagesByVariable []QuestionUsage
+
+func (u questionUsagesByVariable) Len() int           { return len(u) }
+func (u questionUsagesByVariable) Swap(i, j int)      { u[i], u[j] = u[j], u[i] }
+func (u questionUsagesByVariable) Less(i, j int) bool { return u[i].Variable < u[j].Variable }
@@ -0,0 +1,28 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestQuestionUsages(t *testing.T) {
+	usages := QuestionUsages(map[string][]Question{
+		"library:mysql": {{Variable: "PASSWORD", Type: "password"}, {Variable: "PORT", Type: "int"}, {Label: "no variable"}},
+		"library:redis": {{Variable: "PORT"}, {Variable: "PASSWORD", Type: "password"}},
+		"library:nginx": {{Variable: "PORT", Type: "int"}},
+	})
+	expected := []QuestionUsage{
+		{Variable: "PASSWORD", Types: []string{"password"}, Templates: []string{"library:mysql", "library:redis"}},
+		{Variable: "PORT", Types: []string{"int", "string"}, Templates: []string{"library:mysql", "library:nginx", "library:redis"}},
+	}
+	for i := range expected {
+		expected[i].Type = "questionUsage"
+	}
+	if !reflect.DeepEqual(usages, expected) {
+		t.Fatalf("Expected the question usages %+v, got %+v", expected, usages)
+	}
+
+	if usages := QuestionUsages(nil); len(usages) != 0 {
+		t.Errorf("Expected no question usages without templates, got %+v", usages)
+	}
+}
@@ -0,0 +1,88 @@
+package model
+
+import "strconv"
+
+//JSONSchemaDraft is the JSON Schema version the questions schema conforms to
+const JSONSchemaDraft string = "http://json-schema.org/draft-04/schema#"
+
+//QuestionsJSONSchema converts the questions of a template into a JSON Schema document describing the answers
+func QuestionsJSONSchema(questions []Question) map[string]interface{} {
+	properties := make(map[string]interface{})
+	required := []string{}
+
+	for _, question := range questions {
+		if question.Variable == "" {
+			continue
+		}
+		properties[question.Variable] = questionJSONSchema(question)
+		if question.Required {
+			required = append(required, question.Variable)
+		}
+	}
+
+	schema := map[string]interface{}{
+		"$schema":    JSONSchemaDraft,
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+func questionJSONSchema(question Question) map[string]interface{} {
+	property := make(map[string]interface{})
+	if question.Label != "" {
+		property["title"] = question.Label
+	}
+	if question.Description != "" {
+		property["description"] = question.Description
+	}
+
+	switch question.Type {
+	case "int":
+		property["type"] = "integer"
+		if min, ok := question.MinValue(); ok {
+			property["minimum"] = min
+		}
+		if max, ok := question.MaxValue(); ok {
+			property["maximum"] = max
+		}
+		if question.Default != "" {
+			if value, err := strconv.Atoi(question.Default); err == nil {
+				property["default"] = value
+			}
+		}
+	case "boolean":
+		property["type"] = "boolean"
+		if question.Default != "" {
+			if value, err := strconv.ParseBool(question.Default); err == nil {
+				property["default"] = value
+			}
+		}
+	default:
+		property["type"] = "string"
+		if question.Type == "password" || question.Secret {
+			property["format"] = "password"
+		}
+		if question.MinLength != 0 {
+			property["minLength"] = question.MinLength
+		}
+		if question.MaxLength != 0 {
+			property["maxLength"] = question.MaxLength
+		}
+		if question.Default != "" {
+			property["default"] = question.Default
+		}
+	}
+
+	if len(question.Options) > 0 {
+		property["enum"] = question.Options
+	}
+	if question.Secret {
+		//tells clients to mask the answer and not to store it, validators of draft 4 ignore the keyword
+		property["writeOnly"] = true
+	}
+	return property
+}
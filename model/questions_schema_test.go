@@ -0,0 +1,71 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestQuestionsJSONSchema(t *testing.T) {
+	schema := QuestionsJSONSchema([]Question{
+		{
+			Variable: "PORT",
+			Label:    "Port",
+			Type:     "int",
+			Required: true,
+			Default:  "6379",
+			Max:      65535,
+		},
+		{
+			Variable: "DEBUG",
+			Type:     "boolean",
+			Default:  "true",
+		},
+		{
+			Variable: "MODE",
+			Type:     "enum",
+			Options:  []string{"master", "slave"},
+			Default:  "master",
+		},
+		{
+			Variable: "TOKEN",
+			Type:     "secret",
+			Secret:   true,
+		},
+		{
+			Label: "No variable",
+		},
+	})
+
+	if schema["$schema"] != JSONSchemaDraft || schema["type"] != "object" {
+		t.Fatal("Schema header incorrect")
+	}
+
+	if !reflect.DeepEqual(schema["required"], []string{"PORT"}) {
+		t.Fatalf("Required questions incorrect: %v", schema["required"])
+	}
+
+	properties := schema["properties"].(map[string]interface{})
+	if len(properties) != 4 {
+		t.Fatalf("Expected 4 properties, got %d", len(properties))
+	}
+
+	port := properties["PORT"].(map[string]interface{})
+	if port["type"] != "integer" || port["default"] != 6379 || port["maximum"] != 65535 || port["title"] != "Port" {
+		t.Fatalf("Int question converted incorrectly: %v", port)
+	}
+
+	debug := properties["DEBUG"].(map[string]interface{})
+	if debug["type"] != "boolean" || debug["default"] != true {
+		t.Fatalf("Boolean question converted incorrectly: %v", debug)
+	}
+
+	mode := properties["MODE"].(map[string]interface{})
+	if mode["type"] != "string" || !reflect.DeepEqual(mode["enum"], []string{"master", "slave"}) {
+		t.Fatalf("Enum question converted incorrectly: %v", mode)
+	}
+
+	token := properties["TOKEN"].(map[string]interface{})
+	if token["type"] != "string" || token["format"] != "password" || token["writeOnly"] != true {
+		t.Fatalf("Secret question converted incorrectly: %v", token)
+	}
+}
@@ -1,5 +1,7 @@
 package model
 
+import "strings"
+
 //import "github.com/rancher/rancher-compose/rancher"
 
 //Question holds the properties of a question present in rancher-compose.yml file
@@ -18,6 +20,88 @@ type Question struct {
 	Options      []string `json:"options" yaml:"options,omitempty"`
 	ValidChars   string   `json:"validChars" yaml:"valid_chars,omitempty"`
 	InvalidChars string   `json:"invalidChars" yaml:"invalid_chars,omitempty"`
+	Secret       bool     `json:"secret" yaml:"secret,omitempty"`
+
+	//minSet and maxSet tell that the rancher-compose.yml set min and max, even to 0
+	minSet bool
+	maxSet bool
+}
+
+//MinValue returns the minimum of an int question, false if it has none
+func (question Question) MinValue() (int, bool) {
+	return question.Min, question.minSet || question.Min != 0
+}
+
+//MaxValue returns the maximum of an int question, false if it has none
+func (question Question) MaxValue() (int, bool) {
+	return question.Max, question.maxSet || question.Max != 0
+}
+
+//UnmarshalYAML reads a question, accepting the camel case spelling of the validation constraints too,
+//section as another name for the group the question is shown in, marking the questions of type secret
+//or flagged sensitive as secret, and remembering a min or max of 0 as set
+func (question *Question) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plainQuestion Question
+	if err := unmarshal((*plainQuestion)(question)); err != nil {
+		return err
+	}
+
+	camelCase := struct {
+		MinLength    int    `yaml:"minLength,omitempty"`
+		MaxLength    int    `yaml:"maxLength,omitempty"`
+		ValidChars   string `yaml:"validChars,omitempty"`
+		InvalidChars string `yaml:"invalidChars,omitempty"`
+		Section      string `yaml:"section,omitempty"`
+		Sensitive    bool   `yaml:"sensitive,omitempty"`
+		Min          *int   `yaml:"min,omitempty"`
+		Max          *int   `yaml:"max,omitempty"`
+	}{}
+	if err := unmarshal(&camelCase); err != nil {
+		return err
+	}
+	if question.MinLength == 0 {
+		question.MinLength = camelCase.MinLength
+	}
+	if question.MaxLength == 0 {
+		question.MaxLength = camelCase.MaxLength
+	}
+	if question.ValidChars == "" {
+		question.ValidChars = camelCase.ValidChars
+	}
+	if question.InvalidChars == "" {
+		question.InvalidChars = camelCase.InvalidChars
+	}
+	if question.Group == "" {
+		question.Group = camelCase.Section
+	}
+	question.minSet = camelCase.Min != nil
+	question.maxSet = camelCase.Max != nil
+	if camelCase.Sensitive || question.Type == "secret" {
+		question.Secret = true
+	}
+	return nil
+}
+
+//MergeQuestions returns the questions followed by the default questions whose variable none of them asks for, so
+//that a question of the template takes precedence over a default question of the same variable
+func MergeQuestions(questions []Question, defaults []Question) []Question {
+	if len(defaults) == 0 {
+		return questions
+	}
+	asked := make(map[string]bool)
+	merged := []Question{}
+	for _, question := range questions {
+		asked[question.Variable] = true
+		merged = append(merged, question)
+	}
+	for _, question := range defaults {
+		if asked[question.Variable] {
+			continue
+		}
+		asked[question.Variable] = true
+		merged = append(merged, question)
+	}
+	return merged
 }
 
 //Output holds the outputs of the template
@@ -38,4 +122,74 @@ type RancherCompose struct {
 	Labels                map[string]string `json:"labels" yaml:"labels,omitempty"`
 	UpgradeFrom           string            `json:"upgradeFrom" yaml:"upgrade_from,omitempty"`
 	MaximumRancherVersion string            `json:"maximumRancherVersion" yaml:"maximum_rancher_version,omitempty"`
+	Stage                 string            `json:"stage" yaml:"stage,omitempty"`
+	Yanked                bool              `json:"yanked" yaml:"yanked,omitempty"`
+	QuestionsVersion      string            `json:"questionsVersion" yaml:"questionsVersion,omitempty"`
+}
+
+//SplitYAMLDocuments splits a YAML stream into its documents, separated by --- lines and optionally ended by ...
+//lines, documents holding nothing but white space and comments are dropped
+func SplitYAMLDocuments(content []byte) [][]byte {
+	var documents [][]byte
+	var document []string
+	hasContent := false
+	for _, line := range strings.Split(string(content), "\n") {
+		marker := strings.TrimRight(line, " \t\r")
+		if marker == "---" || marker == "..." || strings.HasPrefix(marker, "--- #") {
+			if hasContent {
+				documents = append(documents, []byte(strings.Join(document, "\n")+"\n"))
+			}
+			document, hasContent = nil, false
+			continue
+		}
+		document = append(document, line)
+		if trimmed := strings.TrimSpace(line); trimmed != "" && !strings.HasPrefix(trimmed, "#") {
+			hasContent = true
+		}
+	}
+	if hasContent {
+		documents = append(documents, []byte(strings.Join(document, "\n")))
+	}
+	return documents
+}
+
+//MergeRancherCompose merges the catalog config read from another document of a rancher-compose file:
+//the questions of variables not asked yet are appended, labels not set yet are added, and the other
+//properties are kept from the first document that sets them
+func MergeRancherCompose(into *RancherCompose, from *RancherCompose) {
+	asked := make(map[string]bool)
+	for _, question := range into.Questions {
+		asked[question.Variable] = true
+	}
+	for _, question := range from.Questions {
+		if !asked[question.Variable] {
+			into.Questions = append(into.Questions, question)
+			asked[question.Variable] = true
+		}
+	}
+	for key, value := range from.Labels {
+		if into.Labels == nil {
+			into.Labels = make(map[string]string)
+		}
+		if _, ok := into.Labels[key]; !ok {
+			into.Labels[key] = value
+		}
+	}
+
+	firstSet := func(value *string, other string) {
+		if *value == "" {
+			*value = other
+		}
+	}
+	firstSet(&into.Name, from.Name)
+	firstSet(&into.UUID, from.UUID)
+	firstSet(&into.Description, from.Description)
+	firstSet(&into.Version, from.Version)
+	firstSet(&into.MinimumRancherVersion, from.MinimumRancherVersion)
+	firstSet(&into.MaximumRancherVersion, from.MaximumRancherVersion)
+	firstSet(&into.UpgradeFrom, from.UpgradeFrom)
+	firstSet(&into.Stage, from.Stage)
+	firstSet(&into.Output.URL, from.Output.URL)
+	firstSet(&into.QuestionsVersion, from.QuestionsVersion)
+	into.Yanked = into.Yanked || from.Yanked
 }
@@ -0,0 +1,81 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitYAMLDocuments(t *testing.T) {
+	content := "# header comment\n---\na: 1\n--- # second\nb: 2\n...\n---\n# only a comment\n---\nc: 3"
+	documents := SplitYAMLDocuments([]byte(content))
+	expected := []string{"a: 1\n", "b: 2\n", "c: 3"}
+	if len(documents) != len(expected) {
+		t.Fatalf("Expected %d documents, got %d: %q", len(expected), len(documents), documents)
+	}
+	for i := range expected {
+		if string(documents[i]) != expected[i] {
+			t.Errorf("Expected document %d to be %q, got %q", i, expected[i], documents[i])
+		}
+	}
+
+	single := SplitYAMLDocuments([]byte("a: 1\n"))
+	if len(single) != 1 || string(single[0]) != "a: 1\n" {
+		t.Errorf("Unexpected documents of a single document stream: %q", single)
+	}
+}
+
+func TestMergeRancherCompose(t *testing.T) {
+	into := &RancherCompose{
+		Name:      "first",
+		Questions: []Question{{Variable: "A", Label: "first A"}},
+		Labels:    map[string]string{"x": "1"},
+	}
+	MergeRancherCompose(into, &RancherCompose{
+		Name:      "second",
+		Version:   "1.0",
+		Questions: []Question{{Variable: "A", Label: "second A"}, {Variable: "B"}},
+		Labels:    map[string]string{"x": "2", "y": "3"},
+		Yanked:    true,
+	})
+
+	if into.Name != "first" || into.Version != "1.0" {
+		t.Errorf("Unexpected name %q and version %q", into.Name, into.Version)
+	}
+	expectedQuestions := []Question{{Variable: "A", Label: "first A"}, {Variable: "B"}}
+	if !reflect.DeepEqual(into.Questions, expectedQuestions) {
+		t.Errorf("Expected questions %v, got %v", expectedQuestions, into.Questions)
+	}
+	if !reflect.DeepEqual(into.Labels, map[string]string{"x": "1", "y": "3"}) {
+		t.Errorf("Unexpected labels %v", into.Labels)
+	}
+	if !into.Yanked {
+		t.Errorf("Expected the version to be yanked by the second document")
+	}
+}
+
+func TestMergeQuestions(t *testing.T) {
+	questions := []Question{
+		{Variable: "TAG", Default: "latest"},
+		{Variable: "COST_CENTER", Default: "web"},
+	}
+	defaults := []Question{
+		{Variable: "COST_CENTER", Default: "shared"},
+		{Variable: "OWNER"},
+		{Variable: "OWNER", Default: "twice"},
+	}
+	merged := MergeQuestions(questions, defaults)
+	expected := []Question{
+		{Variable: "TAG", Default: "latest"},
+		{Variable: "COST_CENTER", Default: "web"},
+		{Variable: "OWNER"},
+	}
+	if !reflect.DeepEqual(merged, expected) {
+		t.Fatalf("Expected %v, got %v", expected, merged)
+	}
+	if len(questions) != 2 {
+		t.Fatalf("Expected the template questions to be left alone, got %v", questions)
+	}
+	if merged := MergeQuestions(questions, nil); !reflect.DeepEqual(merged, questions) {
+		t.Fatalf("Expected %v without default questions, got %v", questions, merged)
+	}
+}
@@ -0,0 +1,16 @@
+package model
+
+import "github.com/rancher/go-rancher/client"
+
+//RecloneStatus structure describes a catalog cloned again from scratch
+type RecloneStatus struct {
+	client.Resource
+	CatalogID string `json:"catalogId"`
+	SHA       string `json:"sha"`
+}
+
+//RecloneStatusCollection holds a collection of reclone statuses
+type RecloneStatusCollection struct {
+	client.Collection
+	Data []RecloneStatus `json:"data,omitempty"`
+}
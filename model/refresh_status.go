@@ -0,0 +1,19 @@
+package model
+
+import "github.com/rancher/go-rancher/client"
+
+//RefreshStatus structure describes a catalog refresh in progress
+type RefreshStatus struct {
+	client.Resource
+	CatalogID      string `json:"catalogId"`
+	State          string `json:"state"`
+	StartedAt      string `json:"startedAt"`
+	RunningSeconds int64  `json:"runningSeconds"`
+	FollowUp       bool   `json:"followUp"`
+}
+
+//RefreshStatusCollection holds a collection of refresh statuses
+type RefreshStatusCollection struct {
+	client.Collection
+	Data []RefreshStatus `json:"data,omitempty"`
+}
@@ -0,0 +1,90 @@
+package model
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/rancher/go-rancher/client"
+)
+
+//composeVariable matches the $VAR and ${VAR} placeholders of a compose file, along with the $$ escape
+var composeVariable = regexp.MustCompile(`\$\$|\$\{([A-Za-z_][A-Za-z0-9_]*)\}|\$([A-Za-z_][A-Za-z0-9_]*)`)
+
+//RenderedTemplate structure holds the compose files of a template version with the answers substituted
+type RenderedTemplate struct {
+	client.Resource
+	TemplateVersionID string            `json:"templateVersionId"`
+	Files             map[string]string `json:"files"`
+}
+
+//AnswerValues returns the value of every question, the answer given or else the default of the question
+func AnswerValues(questions []Question, answers map[string]string) map[string]string {
+	values := make(map[string]string)
+	for _, question := range questions {
+		if question.Default != "" {
+			values[question.Variable] = question.Default
+		}
+	}
+	for variable, answer := range answers {
+		if answer != "" {
+			values[variable] = answer
+		}
+	}
+	return values
+}
+
+//MissingAnswers returns a message per required question that has neither an answer nor a default
+func MissingAnswers(questions []Question, values map[string]string) []string {
+	errors := []string{}
+	for _, question := range questions {
+		if question.Required && values[question.Variable] == "" {
+			errors = append(errors, fmt.Sprintf("%s: an answer is required", question.Variable))
+		}
+	}
+	sort.Strings(errors)
+	return errors
+}
+
+//DefaultAnswersFile returns an answers file of VARIABLE=value lines giving every question its default, in the
+//order of the questions, the values are written as is like rancher-compose --env-file reads them; a default
+//spanning several lines cannot be written so, its question is left unanswered with a comment telling why
+func DefaultAnswersFile(questions []Question) string {
+	var buffer bytes.Buffer
+	written := make(map[string]bool)
+	for _, question := range questions {
+		if question.Variable == "" || written[question.Variable] {
+			continue
+		}
+		written[question.Variable] = true
+		if strings.ContainsAny(question.Default, "\r\n") {
+			fmt.Fprintf(&buffer, "# %s has a default spanning several lines\n%s=\n", question.Variable, question.Variable)
+			continue
+		}
+		fmt.Fprintf(&buffer, "%s=%s\n", question.Variable, question.Default)
+	}
+	return buffer.String()
+}
+
+//IsComposeFile tells if a template file is a docker-compose or rancher-compose file
+func IsComposeFile(fileName string) bool {
+	name := fileName[strings.LastIndex(fileName, "/")+1:]
+	return strings.HasPrefix(name, "docker-compose") || strings.HasPrefix(name, "rancher-compose")
+}
+
+//InterpolateCompose substitutes the $VAR and ${VAR} placeholders of a compose file with the given values,
+//placeholders without a value and $$ escapes are left for docker-compose to resolve
+func InterpolateCompose(content string, values map[string]string) string {
+	return composeVariable.ReplaceAllStringFunc(content, func(placeholder string) string {
+		if placeholder == "$$" {
+			return placeholder
+		}
+		variable := strings.TrimSuffix(strings.TrimPrefix(strings.TrimPrefix(placeholder, "$"), "{"), "}")
+		if value, ok := values[variable]; ok {
+			return value
+		}
+		return placeholder
+	})
+}
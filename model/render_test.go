@@ -0,0 +1,65 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestInterpolateCompose(t *testing.T) {
+	content := "image: redis:${TAG}\nports:\n- $PORT:6379\ncommand: echo $$HOME ${UNSET} $TAG_SUFFIX\n"
+	rendered := InterpolateCompose(content, map[string]string{
+		"TAG":  "3.2",
+		"PORT": "6380",
+	})
+	expected := "image: redis:3.2\nports:\n- 6380:6379\ncommand: echo $$HOME ${UNSET} $TAG_SUFFIX\n"
+	if rendered != expected {
+		t.Fatalf("Expected %q, got %q", expected, rendered)
+	}
+}
+
+func TestAnswerValuesAndMissingAnswers(t *testing.T) {
+	questions := []Question{
+		{Variable: "TAG", Default: "latest"},
+		{Variable: "PASSWORD", Required: true},
+		{Variable: "PORT", Required: true, Default: "6379"},
+	}
+
+	values := AnswerValues(questions, map[string]string{"TAG": "3.2", "PASSWORD": ""})
+	expected := map[string]string{"TAG": "3.2", "PORT": "6379"}
+	if !reflect.DeepEqual(values, expected) {
+		t.Fatalf("Expected %v, got %v", expected, values)
+	}
+
+	errors := MissingAnswers(questions, values)
+	if !reflect.DeepEqual(errors, []string{"PASSWORD: an answer is required"}) {
+		t.Fatalf("Unexpected missing answers %v", errors)
+	}
+}
+
+func TestDefaultAnswersFile(t *testing.T) {
+	answers := DefaultAnswersFile([]Question{
+		{Variable: "TAG", Default: "latest"},
+		{Variable: "PASSWORD", Type: "password"},
+		{Variable: "CONFIG", Default: "a: 1\nb: 2"},
+		{Variable: "TAG", Default: "other"},
+		{Label: "No variable"},
+	})
+	expected := "TAG=latest\nPASSWORD=\n# CONFIG has a default spanning several lines\nCONFIG=\n"
+	if answers != expected {
+		t.Fatalf("Expected %q, got %q", expected, answers)
+	}
+}
+
+func TestIsComposeFile(t *testing.T) {
+	for fileName, expected := range map[string]bool{
+		"docker-compose.yml":        true,
+		"rancher-compose.yml":       true,
+		"redis/docker-compose.yml":  true,
+		"README.md":                 false,
+		"compose/notes-compose.yml": false,
+	} {
+		if IsComposeFile(fileName) != expected {
+			t.Errorf("Expected IsComposeFile(%q) to be %v", fileName, expected)
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package model
+
+import "strings"
+
+//MatchesSearch tells if every word of the search is found, ignoring case, in the name, the description
+//or one of the keywords of the template
+func MatchesSearch(template *Template, search string) bool {
+	fields := append([]string{template.Name, template.Description}, template.Keywords...)
+	for i := range fields {
+		fields[i] = strings.ToLower(fields[i])
+	}
+	for _, word := range strings.Fields(strings.ToLower(search)) {
+		found := false
+		for _, field := range fields {
+			if strings.Contains(field, word) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,25 @@
+package model
+
+import "testing"
+
+func TestMatchesSearch(t *testing.T) {
+	template := &Template{
+		Name:        "Kubernetes",
+		Description: "Container orchestration",
+		Keywords:    []string{"k8s", "Scheduler"},
+	}
+	for search, expected := range map[string]bool{
+		"":                     true,
+		"kube":                 true,
+		"K8S":                  true,
+		"scheduler":            true,
+		"orchestration k8s":    true,
+		"orchestration swarm":  false,
+		"swarm":                false,
+		"  container   KUBER ": true,
+	} {
+		if MatchesSearch(template, search) != expected {
+			t.Errorf("Expected MatchesSearch(%q) to be %v", search, expected)
+		}
+	}
+}
@@ -0,0 +1,18 @@
+package model
+
+import "github.com/rancher/go-rancher/client"
+
+//SelfTest structure holds the report of the self test, which reads a template version end to end
+type SelfTest struct {
+	client.Resource
+	Passed            bool            `json:"passed"`
+	TemplateVersionID string          `json:"templateVersionId"`
+	Checks            []SelfTestCheck `json:"checks"`
+}
+
+//SelfTestCheck structure holds the result of a step of the self test
+type SelfTestCheck struct {
+	Name    string `json:"name"`
+	Passed  bool   `json:"passed"`
+	Message string `json:"message,omitempty"`
+}
@@ -2,25 +2,44 @@ package model
 
 import "github.com/rancher/go-rancher/client"
 
-//Template structure defines all properties that can be present in a template
+//CertifiedTrust is the trust level of templates vetted by their vendor
+const CertifiedTrust string = "certified"
+
+//DefaultStage is the maturity of template versions that do not declare a stage
+const DefaultStage string = "stable"
+
+//DefaultQuestionsSchema is the version of the questions schema of template versions that do not declare one
+const DefaultQuestionsSchema int = 1
+
+//Template structure defines all properties that can be present in a template, every field has an explicit
+//camelCase json name, which is the name served by the api and stays stable
 type Template struct {
 	client.Resource
-	CatalogID                        string            `json:"catalogId"`
-	Name                             string            `json:"name"`
-	Category                         string            `json:"category"`
-	IsSystem                         string            `json:"isSystem"`
-	Description                      string            `json:"description"`
-	Version                          string            `json:"version"`
-	DefaultVersion                   string            `json:"defaultVersion"`
-	IconLink                         string            `json:"iconLink"`
-	VersionLinks                     map[string]string `json:"versionLinks"`
-	UpgradeVersionLinks              map[string]string `json:"upgradeVersionLinks"`
-	Files                            map[string]string `json:"files"`
-	Questions                        []Question        `json:"questions"`
-	Path                             string            `json:"path"`
-	MinimumRancherVersion            string            `json:"minimumRancherVersion"`
-	TemplateVersionRancherVersion    map[string]string
-	TemplateVersionRancherVersionGte map[string]string
+	CatalogID             string            `json:"catalogId"`
+	Name                  string            `json:"name"`
+	Category              string            `json:"category"`
+	IsSystem              string            `json:"isSystem"`
+	Description           string            `json:"description"`
+	Version               string            `json:"version"`
+	DefaultVersion        string            `json:"defaultVersion"`
+	UpdatedAt             string            `json:"updatedAt,omitempty"`
+	LatestVersion         string            `json:"latestVersion,omitempty"`
+	IsLatest              bool              `json:"isLatest,omitempty"`
+	IconLink              string            `json:"iconLink"`
+	IconLinkDark          string            `json:"iconLinkDark,omitempty"`
+	VersionLinks          map[string]string `json:"versionLinks"`
+	Versions              []string          `json:"versions,omitempty"`
+	VersionNames          map[string]string `json:"versionNames,omitempty"`
+	VersionCount          int               `json:"versionCount"`
+	UpgradeVersionLinks   map[string]string `json:"upgradeVersionLinks"`
+	Files                 map[string]string `json:"files"`
+	Questions             []Question        `json:"questions"`
+	HasQuestions          bool              `json:"hasQuestions"`
+	Path                  string            `json:"path"`
+	MinimumRancherVersion string            `json:"minimumRancherVersion"`
+	//the rancher version tables are served under the Go names they had before the fields had json names
+	TemplateVersionRancherVersion    map[string]string      `json:"TemplateVersionRancherVersion"`
+	TemplateVersionRancherVersionGte map[string]string      `json:"TemplateVersionRancherVersionGte"`
 	Maintainer                       string                 `json:"maintainer"`
 	License                          string                 `json:"license"`
 	ProjectURL                       string                 `json:"projectURL"`
@@ -31,6 +50,30 @@ type Template struct {
 	UpgradeFrom                      string                 `json:"upgradeFrom"`
 	Bindings                         map[string]interface{} `json:"bindings"`
 	MaximumRancherVersion            string                 `json:"maximumRancherVersion"`
+	MinimumMemory                    string                 `json:"minimumMemory,omitempty"`
+	RecommendedMemory                string                 `json:"recommendedMemory,omitempty"`
+	MinimumCPU                       string                 `json:"minimumCPU,omitempty"`
+	RecommendedCPU                   string                 `json:"recommendedCPU,omitempty"`
+	ChartURLs                        []string               `json:"chartUrls,omitempty"`
+	Trust                            string                 `json:"trust,omitempty"`
+	Weight                           int                    `json:"weight,omitempty"`
+	InstallNotes                     string                 `json:"installNotes,omitempty"`
+	Stage                            string                 `json:"stage,omitempty"`
+	VersionStages                    map[string]string      `json:"versionStages,omitempty"`
+	Yanked                           bool                   `json:"yanked,omitempty"`
+	YankedVersions                   []string               `json:"yankedVersions,omitempty"`
+	QuestionsSchema                  string                 `json:"questionsSchema,omitempty"`
+	VersionQuestionsSchemas          map[string]string      `json:"versionQuestionsSchemas,omitempty"`
+	Aliases                          []string               `json:"aliases,omitempty"`
+	Keywords                         []string               `json:"keywords,omitempty"`
+	Dependencies                     []string               `json:"dependencies,omitempty"`
+	Environments                     []string               `json:"environments,omitempty"`
+	Images                           []string               `json:"images,omitempty"`
+	SizeBytes                        int64                  `json:"sizeBytes,omitempty"`
+	FileCount                        int                    `json:"fileCount,omitempty"`
+	Screenshots                      []string               `json:"screenshots,omitempty"`
+	ComposeVersion                   string                 `json:"composeVersion,omitempty"`
+	DeprecatedSyntax                 bool                   `json:"deprecatedSyntax,omitempty"`
 }
 
 //TemplateCollection holds a collection of templates
@@ -0,0 +1,11 @@
+package model
+
+import "github.com/rancher/go-rancher/client"
+
+//TemplateVersionBatch structure holds several versions of a template read in one request
+type TemplateVersionBatch struct {
+	client.Resource
+	TemplateID string              `json:"templateId"`
+	Versions   map[string]Template `json:"versions"`
+	Missing    []string            `json:"missing,omitempty"`
+}
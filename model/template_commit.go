@@ -0,0 +1,20 @@
+package model
+
+import "github.com/rancher/go-rancher/client"
+
+//TemplateCommit structure describes the last git commit that modified a template or template version
+type TemplateCommit struct {
+	client.Resource
+	TemplateID  string `json:"templateId"`
+	SHA         string `json:"sha"`
+	Author      string `json:"author"`
+	AuthorEmail string `json:"authorEmail"`
+	Date        string `json:"date"`
+	Message     string `json:"message"`
+}
+
+//TemplateCommitCollection holds a page of the commits that modified a template or template version
+type TemplateCommitCollection struct {
+	client.Collection
+	Data []TemplateCommit `json:"data,omitempty"`
+}
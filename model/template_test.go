@@ -0,0 +1,53 @@
+package model
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"unicode"
+)
+
+//legacyFieldNames are the fields served under their Go names since before the fields had json names
+var legacyFieldNames = map[string]bool{
+	"TemplateVersionRancherVersion":    true,
+	"TemplateVersionRancherVersionGte": true,
+}
+
+func TestTemplateFieldsHaveCamelCaseNames(t *testing.T) {
+	templateType := reflect.TypeOf(Template{})
+	for i := 0; i < templateType.NumField(); i++ {
+		field := templateType.Field(i)
+		if field.Anonymous {
+			continue
+		}
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "" {
+			t.Errorf("Field %s has no json name", field.Name)
+			continue
+		}
+		if legacyFieldNames[field.Name] {
+			if name != field.Name {
+				t.Errorf("Field %s has the json name %s, it must keep the name it has always been served under", field.Name, name)
+			}
+			continue
+		}
+		if !unicode.IsLower(rune(name[0])) || strings.Contains(name, "_") {
+			t.Errorf("Field %s has the json name %s, which is not camel case", field.Name, name)
+		}
+	}
+}
+
+func TestTemplateJSONNames(t *testing.T) {
+	content, err := json.Marshal(&Template{DefaultVersion: "1.0", CatalogID: "library"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fields := make(map[string]interface{})
+	if err := json.Unmarshal(content, &fields); err != nil {
+		t.Fatal(err)
+	}
+	if fields["defaultVersion"] != "1.0" || fields["catalogId"] != "library" {
+		t.Errorf("Unexpected serialization %s", content)
+	}
+}
@@ -0,0 +1,12 @@
+package model
+
+import "github.com/rancher/go-rancher/client"
+
+//TemplateUpgrades structure lists the versions a template version can be upgraded to
+type TemplateUpgrades struct {
+	client.Resource
+	TemplateID   string            `json:"templateId"`
+	From         string            `json:"from"`
+	Versions     []string          `json:"versions"`
+	VersionLinks map[string]string `json:"versionLinks"`
+}
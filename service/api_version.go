@@ -0,0 +1,47 @@
+package service
+
+import (
+	"net/http"
+
+	"github.com/rancher/go-rancher/api"
+	"github.com/rancher/go-rancher/client"
+	"github.com/rancher/rancher-catalog-service/manager"
+)
+
+//apiVersion is the v1-catalog resource, which describes the catalogs served next to the collection links
+type apiVersion struct {
+	client.Resource
+	Catalogs []manager.Catalog `json:"catalogs"`
+}
+
+//GetAPIVersion is a handler for route /v1-catalog and returns the links to the collections of the api
+//along with the name, description and logo of every catalog
+func GetAPIVersion(w http.ResponseWriter, r *http.Request) {
+	apiContext := api.GetApiContext(r)
+
+	version := apiVersion{
+		Resource: client.Resource{
+			Id:    "v1-catalog",
+			Type:  "apiVersion",
+			Links: map[string]string{},
+		},
+		Catalogs: []manager.Catalog{},
+	}
+	for _, schema := range schemas.Data {
+		for _, method := range schema.CollectionMethods {
+			if method == "GET" {
+				version.Links[schema.PluralName] = apiContext.UrlBuilder.Collection(schema.Id)
+			}
+		}
+	}
+
+	for _, catalog := range manager.ListAllCatalogs() {
+		catalog.Links = map[string]string{
+			"self":      URLEncoded(apiContext.UrlBuilder.ReferenceByIdLink("catalog", catalog.Id)),
+			"templates": URLEncoded(apiContext.UrlBuilder.ReferenceByIdLink("catalog", catalog.CatalogLink)),
+		}
+		version.Catalogs = append(version.Catalogs, catalog)
+	}
+
+	apiContext.Write(&version)
+}
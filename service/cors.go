@@ -0,0 +1,48 @@
+package service
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/rancher/rancher-catalog-service/manager"
+)
+
+//corsAllowedMethods are the methods the API is served on
+const corsAllowedMethods string = "GET, HEAD, POST, OPTIONS"
+
+//corsMaxAgeSeconds is how long browsers may cache a preflight response
+const corsMaxAgeSeconds string = "600"
+
+//handleCORS adds the CORS headers for requests from an origin allowed by -corsOrigins
+//and answers preflight requests, it returns true when the request has been answered
+func handleCORS(w http.ResponseWriter, r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" || !corsOriginAllowed(origin) {
+		return false
+	}
+
+	w.Header().Set("Access-Control-Allow-Origin", origin)
+	w.Header().Add("Vary", "Origin")
+
+	if r.Method != "OPTIONS" || r.Header.Get("Access-Control-Request-Method") == "" {
+		return false
+	}
+
+	w.Header().Set("Access-Control-Allow-Methods", corsAllowedMethods)
+	if requestHeaders := r.Header.Get("Access-Control-Request-Headers"); requestHeaders != "" {
+		w.Header().Set("Access-Control-Allow-Headers", requestHeaders)
+	}
+	w.Header().Set("Access-Control-Max-Age", corsMaxAgeSeconds)
+	w.WriteHeader(http.StatusNoContent)
+	return true
+}
+
+func corsOriginAllowed(origin string) bool {
+	for _, allowed := range strings.Split(*manager.CorsOrigins, ",") {
+		allowed = strings.TrimSpace(allowed)
+		if allowed == "*" || (allowed != "" && strings.EqualFold(allowed, origin)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,85 @@
+package service
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/context"
+)
+
+//etagRoutes lists the routes whose responses carry an ETag, HEAD requests to them being answered without a body
+var etagRoutes = map[string]bool{
+	"LoadTemplateDetails":        true,
+	"LoadTemplateVersionDetails": true,
+	"HeadTemplateDetails":        true,
+	"HeadTemplateVersionDetails": true,
+}
+
+//etagResponseWriter holds back the response of a handler until its ETag is known
+type etagResponseWriter struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (e *etagResponseWriter) Header() http.Header {
+	return e.header
+}
+
+func (e *etagResponseWriter) WriteHeader(status int) {
+	e.status = status
+}
+
+func (e *etagResponseWriter) Write(content []byte) (int, error) {
+	return e.body.Write(content)
+}
+
+//withETag serves the request through the handler and tags a successful response with the hash of its body,
+//answering 304 to a matching If-None-Match; HEAD requests get the status and headers of GET without the body.
+//A compressed response is tagged apart from the uncompressed one, its body being different
+func withETag(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		head := r.Method == "HEAD"
+		request := r
+		if head {
+			//the handler has to produce the GET body for the ETag to match the one of GET, it is given a copy
+			//of the request along with the route variables and request logger held for the request
+			getRequest := *r
+			getRequest.Method = "GET"
+			for key, value := range context.GetAll(r) {
+				context.Set(&getRequest, key, value)
+			}
+			defer context.Clear(&getRequest)
+			request = &getRequest
+		}
+
+		recorder := &etagResponseWriter{header: w.Header(), status: http.StatusOK}
+		handler.ServeHTTP(recorder, request)
+
+		if recorder.status == http.StatusOK {
+			if w.Header().Get("Content-Type") == "" {
+				//the type the response would be detected as, which tells whether it is compressed
+				w.Header().Set("Content-Type", http.DetectContentType(recorder.body.Bytes()))
+			}
+			etag := fmt.Sprintf("%x", sha1.Sum(recorder.body.Bytes()))
+			if gzipWriter, ok := w.(*gzipResponseWriter); ok && gzipWriter.compresses(recorder.status) {
+				etag += "-gzip"
+			}
+			etag = "\"" + etag + "\""
+			w.Header().Set("ETag", etag)
+			if r.Header.Get("If-None-Match") == etag {
+				w.Header().Del("Content-Length")
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(recorder.body.Len()))
+		w.WriteHeader(recorder.status)
+		if !head {
+			w.Write(recorder.body.Bytes())
+		}
+	})
+}
@@ -0,0 +1,142 @@
+package service
+
+import (
+	"compress/gzip"
+	"net/http"
+	"strings"
+
+	"github.com/rancher/rancher-catalog-service/manager"
+)
+
+//gzipResponseWriter compresses the response body unless its content type is already compressed,
+//the decision is taken once the headers of the response are known
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	gzipWriter *gzip.Writer
+	decided    bool
+	//pendingStatus is the status written before the content type was set, the header is sent with the first
+	//write so that the content type is detected from the uncompressed body
+	pendingStatus int
+}
+
+//gzipHandler serves the request through the handler, compressing the response if the client accepts gzip
+func gzipHandler(handler http.Handler, w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Vary", "Accept-Encoding")
+	if r.Method == "HEAD" || r.Header.Get("Range") != "" || !acceptsGzip(r) {
+		handler.ServeHTTP(w, r)
+		return
+	}
+
+	gzipWriter := &gzipResponseWriter{ResponseWriter: w}
+	defer gzipWriter.close()
+	handler.ServeHTTP(gzipWriter, r)
+}
+
+func acceptsGzip(r *http.Request) bool {
+	for _, encoding := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		tokens := strings.Split(encoding, ";")
+		if strings.TrimSpace(tokens[0]) != "gzip" {
+			continue
+		}
+		if len(tokens) > 1 && strings.Replace(tokens[1], " ", "", -1) == "q=0" {
+			return false
+		}
+		return true
+	}
+	return false
+}
+
+//compressedContentType checks if content of the given type gains nothing from compression
+func compressedContentType(contentType string) bool {
+	contentType = strings.ToLower(contentType)
+	if strings.HasPrefix(contentType, "image/") {
+		return !strings.HasPrefix(contentType, "image/svg")
+	}
+	for _, compressed := range []string{"application/gzip", "application/x-gzip", "application/zip", "application/x-tar"} {
+		if strings.HasPrefix(contentType, compressed) {
+			return true
+		}
+	}
+	return false
+}
+
+//compresses tells if a response of the given status, with the headers set so far, is compressed
+func (g *gzipResponseWriter) compresses(status int) bool {
+	header := g.Header()
+	return status != http.StatusNoContent && status != http.StatusNotModified && header.Get("Content-Encoding") == "" && !compressedContentType(header.Get("Content-Type"))
+}
+
+func (g *gzipResponseWriter) decide(status int) {
+	if g.decided {
+		return
+	}
+	g.decided = true
+
+	if !g.compresses(status) {
+		return
+	}
+	header := g.Header()
+	header.Set("Content-Encoding", "gzip")
+	header.Del("Content-Length")
+	g.gzipWriter = gzip.NewWriter(g.ResponseWriter)
+}
+
+func (g *gzipResponseWriter) WriteHeader(status int) {
+	if g.decided || g.pendingStatus != 0 {
+		return
+	}
+	if g.Header().Get("Content-Type") == "" && status != http.StatusNoContent && status != http.StatusNotModified {
+		g.pendingStatus = status
+		return
+	}
+	g.decide(status)
+	g.ResponseWriter.WriteHeader(status)
+}
+
+func (g *gzipResponseWriter) Write(content []byte) (int, error) {
+	if !g.decided {
+		status := http.StatusOK
+		if g.pendingStatus != 0 {
+			status = g.pendingStatus
+		}
+		if g.Header().Get("Content-Type") == "" {
+			g.Header().Set("Content-Type", http.DetectContentType(content))
+		}
+		g.decide(status)
+		g.ResponseWriter.WriteHeader(status)
+	}
+	if g.gzipWriter != nil {
+		return g.gzipWriter.Write(content)
+	}
+	return g.ResponseWriter.Write(content)
+}
+
+//Flush sends the body compressed so far, so that handlers streaming their response keep doing so
+func (g *gzipResponseWriter) Flush() {
+	g.writePendingHeader()
+	if g.gzipWriter != nil {
+		g.gzipWriter.Flush()
+	}
+	if flusher, ok := g.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+//writePendingHeader sends the status written before the content type was set, for a response without a body
+func (g *gzipResponseWriter) writePendingHeader() {
+	if g.decided || g.pendingStatus == 0 {
+		return
+	}
+	g.decided = true
+	g.ResponseWriter.WriteHeader(g.pendingStatus)
+}
+
+func (g *gzipResponseWriter) close() {
+	g.writePendingHeader()
+	if g.gzipWriter == nil {
+		return
+	}
+	if err := g.gzipWriter.Close(); err != nil {
+		manager.RequestLogger.Debugf("Error completing gzip response, error: %v", err)
+	}
+}
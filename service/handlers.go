@@ -1,22 +1,37 @@
 package service
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/url"
+	"os"
+	"path"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	log "github.com/Sirupsen/logrus"
 	"github.com/blang/semver"
 	"github.com/gorilla/mux"
 	"github.com/rancher/go-rancher/api"
+	"github.com/rancher/go-rancher/client"
 	"github.com/rancher/rancher-catalog-service/manager"
 	"github.com/rancher/rancher-catalog-service/model"
 )
 
 const headerForwardedProto string = "X-Forwarded-Proto"
 
+const (
+	//headerConfigFile names the file of the catalog repo a raw config is read from
+	headerConfigFile string = "X-Catalog-Config-File"
+	//headerConfigInherited tells that the raw config of a template version is the one of its template
+	headerConfigInherited string = "X-Catalog-Config-Inherited"
+)
+
 var (
 	re = regexp.MustCompile(`v([a-zA-Z0-9.]+)`)
 )
@@ -57,7 +72,7 @@ func GetCatalog(w http.ResponseWriter, r *http.Request) {
 		catalog.Links["self"] = URLEncoded(apiContext.UrlBuilder.ReferenceByIdLink("catalog", catalog.Id))
 		apiContext.Write(&catalog)
 	} else {
-		log.Debugf("Cannot find catalog by catalogID: %s", catalogID)
+		requestLog(r).Debugf("Cannot find catalog by catalogID: %s", catalogID)
 		ReturnHTTPError(w, r, http.StatusNotFound, fmt.Sprintf("Cannot find catalog by catalogID: %s", catalogID))
 	}
 
@@ -71,25 +86,84 @@ func GetTemplatesForCatalog(w http.ResponseWriter, r *http.Request) {
 	catalogID := vars["catalogId"]
 
 	if catalogID != "" {
-		log.Debugf("Request to get templates for catalog %s", catalogID)
+		requestLog(r).Debugf("Request to get templates for catalog %s", catalogID)
 		templates := manager.ListTemplatesForCatalog(catalogID)
+		manager.RevalidateStaleCatalogs(catalogID)
 
 		rancherVersion := r.URL.Query().Get("minimumRancherVersion_lte")
 		if rancherVersion != "" {
-			log.Debugf("Request to get all templates under catalog %s with minimumRancherVersion <= %s", catalogID, rancherVersion)
+			requestLog(r).Debugf("Request to get all templates under catalog %s with minimumRancherVersion <= %s", catalogID, rancherVersion)
 		}
 
 		rancherVersionGte := r.URL.Query().Get("maximumRancherVersion_gte")
 		if rancherVersionGte != "" {
-			log.Debugf("Request to get all templates under catalog %s with maximumRancherVersion >= %s", catalogID, rancherVersionGte)
+			requestLog(r).Debugf("Request to get all templates under catalog %s with maximumRancherVersion >= %s", catalogID, rancherVersionGte)
+		}
+
+		trust := r.URL.Query().Get("trust")
+		if trust != "" {
+			requestLog(r).Debugf("Request to get all templates under catalog %s with trust = %s", catalogID, trust)
+		}
+
+		search := r.URL.Query().Get("search")
+		if search != "" {
+			requestLog(r).Debugf("Request to get all templates under catalog %s matching the search %s", catalogID, search)
+		}
+
+		minVersionCount, maxVersionCount, err := getVersionCountFilters(r)
+		if err != nil {
+			ReturnHTTPError(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		hasQuestions, err := getBoolFilter(r, "hasQuestions")
+		if err != nil {
+			ReturnHTTPError(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		includeYanked, err := getBoolFilter(r, "includeYanked")
+		if err != nil {
+			ReturnHTTPError(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		questionsSchema, maxQuestionsSchema, err := getQuestionsSchemaFilters(r)
+		if err != nil {
+			ReturnHTTPError(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		updatedSince, err := getUpdatedWithinFilter(r)
+		if err != nil {
+			ReturnHTTPError(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		order, err := getTemplateOrder(r)
+		if err != nil {
+			ReturnHTTPError(w, r, http.StatusBadRequest, err.Error())
+			return
 		}
 
 		//read the catalog
 		resp := model.TemplateCollection{}
 		for _, value := range templates {
+			if trust != "" && !strings.EqualFold(trust, value.Trust) {
+				continue
+			}
+
+			if !model.MatchesSearch(&value, search) {
+				continue
+			}
+
+			if !updatedAfter(&value, updatedSince) {
+				continue
+			}
+
 			if rancherVersion != "" {
 				var err error
-				value.VersionLinks, err = filterByMinimumRancherVersion(rancherVersion, &value)
+				value.VersionLinks, err = filterByMinimumRancherVersion(requestLog(r), rancherVersion, &value)
 				if err != nil {
 					//cannot apply the filter, return empty set
 					break
@@ -98,24 +172,42 @@ func GetTemplatesForCatalog(w http.ResponseWriter, r *http.Request) {
 
 			if rancherVersionGte != "" {
 				var err error
-				value.VersionLinks, err = filterByMaximumRancherVersion(rancherVersionGte, &value)
+				value.VersionLinks, err = filterByMaximumRancherVersion(requestLog(r), rancherVersionGte, &value)
 				if err != nil {
 					//cannot apply the filter, return empty set
 					break
 				}
 			}
 
+			if questionsSchema != -1 || maxQuestionsSchema != -1 {
+				value.VersionLinks = filterByQuestionsSchema(&value, questionsSchema, maxQuestionsSchema)
+			}
+
+			if includeYanked == nil || !*includeYanked {
+				value.VersionLinks = withoutYankedVersions(&value)
+			}
+
 			//if no versions are present then just skip the template
 			if len(value.VersionLinks) == 0 {
 				continue
 			}
 
-			log.Debugf("Found Template: %s", value.Name)
+			if !versionCountInRange(len(value.VersionLinks), minVersionCount, maxVersionCount) {
+				continue
+			}
+
+			if hasQuestions != nil && value.HasQuestions != *hasQuestions {
+				continue
+			}
+
+			requestLog(r).Debugf("Found Template: %s", value.Name)
 
+			value.VersionCount = len(value.VersionLinks)
 			value.VersionLinks = PopulateTemplateLinks(r, &value)
 			resp.Data = append(resp.Data, value)
 		}
 
+		sortTemplates(resp.Data, order)
 		resp.Actions = make(map[string]string)
 		resp.Actions["refresh"] = api.GetApiContext(r).UrlBuilder.ReferenceByIdLink("template", "") + "?action=refresh"
 		apiContext.Write(&resp)
@@ -134,37 +226,85 @@ func ListTemplates(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if catalogID != "" {
-		log.Debugf("Request to get templates for catalog %s", catalogID)
+		requestLog(r).Debugf("Request to get templates for catalog %s", catalogID)
 		templates = manager.ListTemplatesForCatalog(catalogID)
 	} else {
-		log.Debugf("Request to get templates from all catalogs ")
+		requestLog(r).Debugf("Request to get templates from all catalogs ")
 		templates = manager.ListAllTemplates()
 	}
+	//serve the current templates right away and refresh the stale catalogs behind the scenes
+	manager.RevalidateStaleCatalogs(catalogID)
 
 	//List the filters
 
 	templateBaseEq := r.URL.Query().Get("templateBase_eq")
 	if templateBaseEq != "" {
-		log.Debugf("And only get %s templates ", templateBaseEq)
+		requestLog(r).Debugf("And only get %s templates ", templateBaseEq)
 	}
 	templateBaseNe := r.URL.Query().Get("templateBase_ne")
 	if templateBaseNe != "" {
-		log.Debugf("And not the %s templates ", templateBaseNe)
+		requestLog(r).Debugf("And not the %s templates ", templateBaseNe)
 	}
 
 	rancherVersion := r.URL.Query().Get("minimumRancherVersion_lte")
 	if rancherVersion != "" {
-		log.Debugf("And templates with minimumRancherVersion <= %s", rancherVersion)
+		requestLog(r).Debugf("And templates with minimumRancherVersion <= %s", rancherVersion)
 	}
 
 	rancherVersionGte := r.URL.Query().Get("maximumRancherVersion_gte")
 	if rancherVersionGte != "" {
-		log.Debugf("And templates with maximumRancherVersion >= %s", rancherVersionGte)
+		requestLog(r).Debugf("And templates with maximumRancherVersion >= %s", rancherVersionGte)
 	}
 
 	category := r.URL.Query().Get("category_ne")
 	if category != "" {
-		log.Debugf("And templates with category not = %s", category)
+		requestLog(r).Debugf("And templates with category not = %s", category)
+	}
+
+	trust := r.URL.Query().Get("trust")
+	if trust != "" {
+		requestLog(r).Debugf("And templates with trust = %s", trust)
+	}
+
+	search := r.URL.Query().Get("search")
+	if search != "" {
+		requestLog(r).Debugf("And templates matching the search %s", search)
+	}
+
+	minVersionCount, maxVersionCount, err := getVersionCountFilters(r)
+	if err != nil {
+		ReturnHTTPError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	hasQuestions, err := getBoolFilter(r, "hasQuestions")
+	if err != nil {
+		ReturnHTTPError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	includeYanked, err := getBoolFilter(r, "includeYanked")
+	if err != nil {
+		ReturnHTTPError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	questionsSchema, maxQuestionsSchema, err := getQuestionsSchemaFilters(r)
+	if err != nil {
+		ReturnHTTPError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	updatedSince, err := getUpdatedWithinFilter(r)
+	if err != nil {
+		ReturnHTTPError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	order, err := getTemplateOrder(r)
+	if err != nil {
+		ReturnHTTPError(w, r, http.StatusBadRequest, err.Error())
+		return
 	}
 
 	//read the catalog
@@ -178,9 +318,21 @@ func ListTemplates(w http.ResponseWriter, r *http.Request) {
 			continue
 		}
 
+		if trust != "" && !strings.EqualFold(trust, value.Trust) {
+			continue
+		}
+
+		if !model.MatchesSearch(&value, search) {
+			continue
+		}
+
+		if !updatedAfter(&value, updatedSince) {
+			continue
+		}
+
 		if rancherVersion != "" {
 			var err error
-			value.VersionLinks, err = filterByMinimumRancherVersion(rancherVersion, &value)
+			value.VersionLinks, err = filterByMinimumRancherVersion(requestLog(r), rancherVersion, &value)
 			if err != nil {
 				//cannot apply the filter, return empty set
 				break
@@ -189,18 +341,34 @@ func ListTemplates(w http.ResponseWriter, r *http.Request) {
 
 		if rancherVersionGte != "" {
 			var err error
-			value.VersionLinks, err = filterByMaximumRancherVersion(rancherVersionGte, &value)
+			value.VersionLinks, err = filterByMaximumRancherVersion(requestLog(r), rancherVersionGte, &value)
 			if err != nil {
 				//cannot apply the filter, return empty set
 				break
 			}
 		}
 
+		if questionsSchema != -1 || maxQuestionsSchema != -1 {
+			value.VersionLinks = filterByQuestionsSchema(&value, questionsSchema, maxQuestionsSchema)
+		}
+
+		if includeYanked == nil || !*includeYanked {
+			value.VersionLinks = withoutYankedVersions(&value)
+		}
+
 		//if no versions are present then just skip the template
 		if len(value.VersionLinks) == 0 {
 			continue
 		}
 
+		if !versionCountInRange(len(value.VersionLinks), minVersionCount, maxVersionCount) {
+			continue
+		}
+
+		if hasQuestions != nil && value.HasQuestions != *hasQuestions {
+			continue
+		}
+
 		if category != "" && value.Category != "" {
 			if strings.EqualFold(category, value.Category) {
 				//skip the templates matching the category_ne filter
@@ -208,30 +376,183 @@ func ListTemplates(w http.ResponseWriter, r *http.Request) {
 			}
 		}
 
-		log.Debugf("Found Template: %s", value.Id)
+		requestLog(r).Debugf("Found Template: %s", value.Id)
+		value.VersionCount = len(value.VersionLinks)
 		value.VersionLinks = PopulateTemplateLinks(r, &value)
 		resp.Data = append(resp.Data, value)
 	}
 
+	sortTemplates(resp.Data, order)
 	resp.Actions = make(map[string]string)
 	resp.Actions["refresh"] = api.GetApiContext(r).UrlBuilder.ReferenceByIdLink("template", "") + "?action=refresh"
 	api.GetApiContext(r).Write(&resp)
 }
 
-func filterByMinimumRancherVersion(rancherVersion string, template *model.Template) (map[string]string, error) {
+//getVersionCountFilters reads the minVersionCount and maxVersionCount filters, -1 means the filter is not set
+func getVersionCountFilters(r *http.Request) (int, int, error) {
+	minVersionCount, err := getIntFilter(r, "minVersionCount")
+	if err != nil {
+		return -1, -1, err
+	}
+	if minVersionCount != -1 {
+		requestLog(r).Debugf("And templates with at least %d versions", minVersionCount)
+	}
+
+	maxVersionCount, err := getIntFilter(r, "maxVersionCount")
+	if err != nil {
+		return -1, -1, err
+	}
+	if maxVersionCount != -1 {
+		requestLog(r).Debugf("And templates with at most %d versions", maxVersionCount)
+	}
+	return minVersionCount, maxVersionCount, nil
+}
+
+//getUpdatedWithinFilter reads the updatedWithin filter, a number of days like 7d or a duration like 12h, and
+//returns the time templates must have been updated after, the zero time if the filter is not set
+func getUpdatedWithinFilter(r *http.Request) (time.Time, error) {
+	valueStr := r.URL.Query().Get("updatedWithin")
+	if valueStr == "" {
+		return time.Time{}, nil
+	}
+	var window time.Duration
+	var err error
+	if strings.HasSuffix(valueStr, "d") {
+		var days int
+		days, err = strconv.Atoi(strings.TrimSuffix(valueStr, "d"))
+		window = time.Duration(days) * 24 * time.Hour
+	} else {
+		window, err = time.ParseDuration(valueStr)
+	}
+	if err != nil || window <= 0 {
+		requestLog(r).Errorf("Error loading the passed filter updatedWithin: %s", valueStr)
+		return time.Time{}, fmt.Errorf("Invalid value for filter updatedWithin: %s", valueStr)
+	}
+	requestLog(r).Debugf("And templates updated within %s", valueStr)
+	return time.Now().Add(-window), nil
+}
+
+//getQuestionsSchemaFilters reads the questionsSchema and questionsSchema_lte filters on the version of the
+//questions schema of the template versions, -1 means the filter is not set
+func getQuestionsSchemaFilters(r *http.Request) (int, int, error) {
+	questionsSchema, err := getIntFilter(r, "questionsSchema")
+	if err != nil {
+		return -1, -1, err
+	}
+	if questionsSchema != -1 {
+		requestLog(r).Debugf("And template versions with questions schema %d", questionsSchema)
+	}
+
+	maxQuestionsSchema, err := getIntFilter(r, "questionsSchema_lte")
+	if err != nil {
+		return -1, -1, err
+	}
+	if maxQuestionsSchema != -1 {
+		requestLog(r).Debugf("And template versions with questions schema <= %d", maxQuestionsSchema)
+	}
+	return questionsSchema, maxQuestionsSchema, nil
+}
+
+//filterByQuestionsSchema returns the version links of the template whose questions schema is the given one
+//and at most the given maximum, -1 for either not to check it; versions declaring no schema use the first one
+func filterByQuestionsSchema(template *model.Template, questionsSchema int, maxQuestionsSchema int) map[string]string {
+	copyOfversionLinks := make(map[string]string)
+	for templateVersion, link := range template.VersionLinks {
+		schema := model.DefaultQuestionsSchema
+		if declared := template.VersionQuestionsSchemas[templateVersion]; declared != "" {
+			var err error
+			if schema, err = strconv.Atoi(declared); err != nil {
+				continue
+			}
+		}
+		if questionsSchema != -1 && schema != questionsSchema {
+			continue
+		}
+		if maxQuestionsSchema != -1 && schema > maxQuestionsSchema {
+			continue
+		}
+		copyOfversionLinks[templateVersion] = link
+	}
+	return copyOfversionLinks
+}
+
+//updatedAfter tells if the last commit to the template folder is after the given time, any template passes
+//for the zero time
+func updatedAfter(template *model.Template, since time.Time) bool {
+	if since.IsZero() {
+		return true
+	}
+	updatedAt, err := time.Parse(time.RFC3339, template.UpdatedAt)
+	return err == nil && updatedAt.After(since)
+}
+
+func getIntFilter(r *http.Request, name string) (int, error) {
+	valueStr := r.URL.Query().Get(name)
+	if valueStr == "" {
+		return -1, nil
+	}
+	value, err := strconv.Atoi(valueStr)
+	if err != nil || value < 0 {
+		requestLog(r).Errorf("Error loading the passed filter %s: %s", name, valueStr)
+		return -1, fmt.Errorf("Invalid value for filter %s: %s", name, valueStr)
+	}
+	return value, nil
+}
+
+//getBoolFilter reads a true/false filter, nil means the filter is not set
+func getBoolFilter(r *http.Request, name string) (*bool, error) {
+	valueStr := r.URL.Query().Get(name)
+	if valueStr == "" {
+		return nil, nil
+	}
+	if valueStr != "true" && valueStr != "false" {
+		requestLog(r).Errorf("Error loading the passed filter %s: %s", name, valueStr)
+		return nil, fmt.Errorf("Invalid value for filter %s: %s, expected true or false", name, valueStr)
+	}
+	value := valueStr == "true"
+	return &value, nil
+}
+
+func versionCountInRange(count int, minVersionCount int, maxVersionCount int) bool {
+	if minVersionCount != -1 && count < minVersionCount {
+		return false
+	}
+	if maxVersionCount != -1 && count > maxVersionCount {
+		return false
+	}
+	return true
+}
+
+//withoutYankedVersions returns the version links of the template less the yanked versions, which are
+//only listed on request
+func withoutYankedVersions(template *model.Template) map[string]string {
+	if len(template.YankedVersions) == 0 {
+		return template.VersionLinks
+	}
+	copyOfversionLinks := make(map[string]string)
+	for templateVersion, link := range template.VersionLinks {
+		copyOfversionLinks[templateVersion] = link
+	}
+	for _, templateVersion := range template.YankedVersions {
+		delete(copyOfversionLinks, templateVersion)
+	}
+	return copyOfversionLinks
+}
+
+func filterByMinimumRancherVersion(logger *log.Entry, rancherVersion string, template *model.Template) (map[string]string, error) {
 	copyOfversionLinks := make(map[string]string)
 
-	vB, err := getSemVersion(rancherVersion)
+	vB, err := getSemVersion(logger, rancherVersion)
 	if err != nil {
-		log.Errorf("Error loading the passed filter minimumRancherVersion_lte with semver %s", err.Error())
+		logger.Errorf("Error loading the passed filter minimumRancherVersion_lte with semver %s", err.Error())
 		return copyOfversionLinks, err
 	}
 
 	for templateVersion, minRancherVersion := range template.TemplateVersionRancherVersion {
 		if minRancherVersion != "" {
-			vA, err := getSemVersion(minRancherVersion)
+			vA, err := getSemVersion(logger, minRancherVersion)
 			if err != nil {
-				log.Errorf("Error loading version with semver %s", err.Error())
+				logger.Errorf("Error loading version with semver %s", err.Error())
 				continue
 			}
 
@@ -252,20 +573,20 @@ func filterByMinimumRancherVersion(rancherVersion string, template *model.Templa
 	return copyOfversionLinks, nil
 }
 
-func filterByMaximumRancherVersion(rancherVersion string, template *model.Template) (map[string]string, error) {
+func filterByMaximumRancherVersion(logger *log.Entry, rancherVersion string, template *model.Template) (map[string]string, error) {
 	copyOfversionLinks := make(map[string]string)
 
-	vB, err := getSemVersion(rancherVersion)
+	vB, err := getSemVersion(logger, rancherVersion)
 	if err != nil {
-		log.Errorf("Error loading the passed filter maximumRancherVersion_gte with semver %s", err.Error())
+		logger.Errorf("Error loading the passed filter maximumRancherVersion_gte with semver %s", err.Error())
 		return copyOfversionLinks, err
 	}
 
 	for templateVersion, maxRancherVersion := range template.TemplateVersionRancherVersionGte {
 		if maxRancherVersion != "" {
-			vA, err := getSemVersion(maxRancherVersion)
+			vA, err := getSemVersion(logger, maxRancherVersion)
 			if err != nil {
-				log.Errorf("Error loading version with semver %s", err.Error())
+				logger.Errorf("Error loading version with semver %s", err.Error())
 				continue
 			}
 
@@ -286,27 +607,27 @@ func filterByMaximumRancherVersion(rancherVersion string, template *model.Templa
 	return copyOfversionLinks, nil
 }
 
-func getSemVersion(versionStr string) (*semver.Version, error) {
+func getSemVersion(logger *log.Entry, versionStr string) (*semver.Version, error) {
 	versionStr = re.ReplaceAllString(versionStr, "$1")
 
 	semVersion, err := semver.Make(versionStr)
 	if err != nil {
-		log.Errorf("Error %v loading semver for version string %s", err.Error(), versionStr)
+		logger.Errorf("Error %v loading semver for version string %s", err.Error(), versionStr)
 		return nil, err
 	}
 	return &semVersion, nil
 }
 
-func isMinRancherVersionLTE(templateMinRancherVersion string, rancherVersion string) (bool, error) {
-	vA, err := getSemVersion(templateMinRancherVersion)
+func isMinRancherVersionLTE(logger *log.Entry, templateMinRancherVersion string, rancherVersion string) (bool, error) {
+	vA, err := getSemVersion(logger, templateMinRancherVersion)
 	if err != nil {
-		log.Errorf("Error loading template minRancherVersion %s with semver %s", templateMinRancherVersion, err.Error())
+		logger.Errorf("Error loading template minRancherVersion %s with semver %s", templateMinRancherVersion, err.Error())
 		return false, err
 	}
 
-	vB, err := getSemVersion(rancherVersion)
+	vB, err := getSemVersion(logger, rancherVersion)
 	if err != nil {
-		log.Errorf("Error loading the passed filter minimumRancherVersion_lte %s with semver %s", rancherVersion, err.Error())
+		logger.Errorf("Error loading the passed filter minimumRancherVersion_lte %s with semver %s", rancherVersion, err.Error())
 		return false, err
 	}
 
@@ -316,16 +637,16 @@ func isMinRancherVersionLTE(templateMinRancherVersion string, rancherVersion str
 	return false, nil
 }
 
-func isMaxRancherVersionGTE(templateMaxRancherVersion string, rancherVersion string) (bool, error) {
-	vA, err := getSemVersion(templateMaxRancherVersion)
+func isMaxRancherVersionGTE(logger *log.Entry, templateMaxRancherVersion string, rancherVersion string) (bool, error) {
+	vA, err := getSemVersion(logger, templateMaxRancherVersion)
 	if err != nil {
-		log.Errorf("Error loading template maxRancherVersion %s with semver %s", templateMaxRancherVersion, err.Error())
+		logger.Errorf("Error loading template maxRancherVersion %s with semver %s", templateMaxRancherVersion, err.Error())
 		return false, err
 	}
 
-	vB, err := getSemVersion(rancherVersion)
+	vB, err := getSemVersion(logger, rancherVersion)
 	if err != nil {
-		log.Errorf("Error loading the passed filter maximumRancherVersion_gte %s with semver %s", rancherVersion, err.Error())
+		logger.Errorf("Error loading the passed filter maximumRancherVersion_gte %s with semver %s", rancherVersion, err.Error())
 		return false, err
 	}
 
@@ -339,26 +660,30 @@ func isMaxRancherVersionGTE(templateMaxRancherVersion string, rancherVersion str
 func LoadTemplateDetails(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	templateIDString := vars["catalog_template_version_Id"]
-	log.Debugf("LoadTemplateDetails for template Id: %s", templateIDString)
-	pathTokens := strings.Split(templateIDString, ":")
+	requestLog(r).Debugf("LoadTemplateDetails for template Id: %s", templateIDString)
+	pathTokens, err := splitTemplateID(templateIDString, 2, 3)
+	if err != nil {
+		requestLog(r).Debugf("Malformed template Id: %s, %v", templateIDString, err)
+		ReturnHTTPError(w, r, http.StatusBadRequest, fmt.Sprintf("Malformed template Id %s: %v", templateIDString, err))
+		return
+	}
 
 	var catalogID, templateID, versionID string
-
-	if len(pathTokens) == 2 {
-		catalogID = pathTokens[0]
-		templateID = pathTokens[1]
-	} else if len(pathTokens) == 3 {
-		catalogID = pathTokens[0]
-		templateID = pathTokens[1]
+	catalogID = pathTokens[0]
+	templateID = pathTokens[1]
+	if len(pathTokens) == 3 {
 		versionID = pathTokens[2]
-	} else {
-		log.Debugf("Cannot find metadata for template Id: %s", templateIDString)
-		ReturnHTTPError(w, r, http.StatusNotFound, fmt.Sprintf("Cannot find metadata for template Id: %s", templateIDString))
 	}
+	//a template requested by one of its former names is served under its canonical id
+	templateID = manager.ResolveTemplateAlias(catalogID, templateID)
 
-	if r.URL.RawQuery != "" && strings.EqualFold("image", r.URL.RawQuery) {
+	if isImageRequest(r) {
 		callToRead(catalogID, templateID, versionID, w, r)
-		loadFile(catalogID, templateID, versionID, manager.PathToImage, w, r)
+		imageMap := manager.PathToImage
+		if strings.EqualFold(r.URL.Query().Get("theme"), "dark") && hasDarkImage(catalogID, templateID, versionID) {
+			imageMap = manager.PathToDarkImage
+		}
+		loadFile(catalogID, templateID, versionID, imageMap, w, r)
 		return
 	}
 
@@ -376,59 +701,627 @@ func LoadTemplateDetails(w http.ResponseWriter, r *http.Request) {
 
 }
 
+//GetTemplateQuestionsSchema is a handler returning the questions of a template version as a JSON Schema document
+func GetTemplateQuestionsSchema(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	templateIDString := vars["catalog_template_version_Id"]
+	requestLog(r).Debugf("GetTemplateQuestionsSchema for template Id: %s", templateIDString)
+	pathTokens, err := splitTemplateID(templateIDString, 3, 3)
+	if err != nil {
+		requestLog(r).Debugf("Malformed template version Id: %s, %v", templateIDString, err)
+		ReturnHTTPError(w, r, http.StatusBadRequest, fmt.Sprintf("Malformed template version Id %s: %v", templateIDString, err))
+		return
+	}
+
+	template, ok := manager.ReadTemplateVersionWithLogger(requestLog(r), pathTokens[0], pathTokens[1], pathTokens[2])
+	if !ok {
+		requestLog(r).Debugf("Cannot find template version: %s", templateIDString)
+		ReturnHTTPError(w, r, http.StatusNotFound, fmt.Sprintf("Cannot find template version: %s", templateIDString))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/schema+json")
+	err = json.NewEncoder(w).Encode(model.QuestionsJSONSchema(template.Questions))
+	if err != nil {
+		requestLog(r).Errorf("Error writing questions schema for template version: %s, error: %v", templateIDString, err)
+	}
+}
+
+//GetTemplateUpgrades is a handler returning the versions a template at the version given by the from
+//query parameter can be upgraded to, ordered by semver
+func GetTemplateUpgrades(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	templateIDString := vars["catalog_template_Id"]
+	from := r.URL.Query().Get("from")
+	requestLog(r).Debugf("GetTemplateUpgrades for template Id: %s from version %s", templateIDString, from)
+	pathTokens, err := splitTemplateID(templateIDString, 2, 2)
+	if err != nil {
+		requestLog(r).Debugf("Malformed template Id: %s, %v", templateIDString, err)
+		ReturnHTTPError(w, r, http.StatusBadRequest, fmt.Sprintf("Malformed template Id %s: %v", templateIDString, err))
+		return
+	}
+	if from == "" {
+		ReturnHTTPError(w, r, http.StatusBadRequest, "Query parameter from is required")
+		return
+	}
+	if _, ok := manager.GetTemplateMetadata(pathTokens[0], pathTokens[1]); !ok {
+		requestLog(r).Debugf("Cannot find template: %s", templateIDString)
+		ReturnHTTPError(w, r, http.StatusNotFound, fmt.Sprintf("Cannot find template: %s", templateIDString))
+		return
+	}
+
+	templateMetadata, versions, err := manager.GetUpgradeTargets(pathTokens[0], pathTokens[1], from)
+	if err != nil {
+		ReturnHTTPError(w, r, http.StatusBadRequest, fmt.Sprintf("Invalid version %s: %v", from, err))
+		return
+	}
+
+	rancherVersion := r.URL.Query().Get("minimumRancherVersion_lte")
+	if rancherVersion != "" {
+		templateMetadata.VersionLinks, err = filterByMinimumRancherVersion(requestLog(r), rancherVersion, &templateMetadata)
+		if err != nil {
+			ReturnHTTPError(w, r, http.StatusBadRequest, fmt.Sprintf("Invalid minimumRancherVersion_lte: %s", rancherVersion))
+			return
+		}
+	}
+	rancherVersionGte := r.URL.Query().Get("maximumRancherVersion_gte")
+	if rancherVersionGte != "" {
+		templateMetadata.VersionLinks, err = filterByMaximumRancherVersion(requestLog(r), rancherVersionGte, &templateMetadata)
+		if err != nil {
+			ReturnHTTPError(w, r, http.StatusBadRequest, fmt.Sprintf("Invalid maximumRancherVersion_gte: %s", rancherVersionGte))
+			return
+		}
+	}
+
+	includeYanked, err := getBoolFilter(r, "includeYanked")
+	if err != nil {
+		ReturnHTTPError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	if includeYanked == nil || !*includeYanked {
+		templateMetadata.VersionLinks = withoutYankedVersions(&templateMetadata)
+	}
+
+	upgrades := model.TemplateUpgrades{
+		TemplateID: templateIDString,
+		From:       from,
+		Versions:   []string{},
+	}
+	upgrades.Type = "templateUpgrades"
+	for _, version := range versions {
+		if _, ok := templateMetadata.VersionLinks[version]; ok {
+			upgrades.Versions = append(upgrades.Versions, version)
+		}
+	}
+	upgrades.VersionLinks = PopulateTemplateLinks(r, &templateMetadata)
+	api.GetApiContext(r).Write(&upgrades)
+}
+
+//GetTemplateConfigFile is a handler returning the raw config file a template is read from, so that authors can
+//tell the file the service parsed; template versions have no config file of their own and get the one of
+//their template, which the X-Catalog-Config-Inherited header tells
+func GetTemplateConfigFile(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	templateIDString := vars["catalog_template_version_Id"]
+	requestLog(r).Debugf("GetTemplateConfigFile for template Id: %s", templateIDString)
+	pathTokens, err := splitTemplateID(templateIDString, 2, 3)
+	if err != nil {
+		requestLog(r).Debugf("Malformed template Id: %s, %v", templateIDString, err)
+		ReturnHTTPError(w, r, http.StatusBadRequest, fmt.Sprintf("Malformed template Id %s: %v", templateIDString, err))
+		return
+	}
+
+	if len(pathTokens) == 3 {
+		templateMetadata, _ := manager.GetTemplateMetadata(pathTokens[0], pathTokens[1])
+		found := false
+		for _, link := range templateMetadata.VersionLinks {
+			found = found || link == templateMetadata.Id+":"+pathTokens[2]
+		}
+		if !found {
+			ReturnHTTPError(w, r, http.StatusNotFound, fmt.Sprintf("Cannot find template version: %s", templateIDString))
+			return
+		}
+		w.Header().Set(headerConfigInherited, "true")
+	}
+
+	content, configPath, err := manager.ReadTemplateConfigFile(pathTokens[0], pathTokens[1])
+	if os.IsNotExist(err) {
+		ReturnHTTPError(w, r, http.StatusNotFound, fmt.Sprintf("Cannot find a config file for template: %s", templateIDString))
+		return
+	} else if err != nil {
+		requestLog(r).Errorf("Error reading the config file of template %s: %v", templateIDString, err)
+		ReturnHTTPError(w, r, http.StatusInternalServerError, fmt.Sprintf("Cannot read the config file of template %s: %v", templateIDString, err))
+		return
+	}
+
+	w.Header().Set(headerConfigFile, configPath)
+	if path.Ext(configPath) == ".json" {
+		w.Header().Set("Content-Type", "application/json")
+	} else {
+		w.Header().Set("Content-Type", "text/yaml")
+	}
+	w.Write(content)
+}
+
+//GetTemplateScreenshot is a handler serving a screenshot of a template or of a template version, with the
+//content type of its image format
+func GetTemplateScreenshot(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	templateIDString := vars["catalog_template_version_Id"]
+	fileName := vars["file_name"]
+	requestLog(r).Debugf("GetTemplateScreenshot %s for template Id: %s", fileName, templateIDString)
+	pathTokens, err := splitTemplateID(templateIDString, 2, 3)
+	if err != nil {
+		requestLog(r).Debugf("Malformed template Id: %s, %v", templateIDString, err)
+		ReturnHTTPError(w, r, http.StatusBadRequest, fmt.Sprintf("Malformed template Id %s: %v", templateIDString, err))
+		return
+	}
+
+	versionID := ""
+	if len(pathTokens) == 3 {
+		versionID = pathTokens[2]
+	}
+	templateID := manager.ResolveTemplateAlias(pathTokens[0], pathTokens[1])
+	filePath, contentType, ok := manager.ScreenshotFile(pathTokens[0], templateID, versionID, fileName)
+	if !ok {
+		ReturnHTTPError(w, r, http.StatusNotFound, fmt.Sprintf("Cannot find the screenshot %s of template: %s", fileName, templateIDString))
+		return
+	}
+	file, err := os.Open(filePath)
+	if err != nil {
+		requestLog(r).Errorf("Error reading the screenshot %s of template %s: %v", fileName, templateIDString, err)
+		ReturnHTTPError(w, r, http.StatusInternalServerError, fmt.Sprintf("Cannot read the screenshot %s of template %s", fileName, templateIDString))
+		return
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		requestLog(r).Errorf("Error reading the screenshot %s of template %s: %v", fileName, templateIDString, err)
+		ReturnHTTPError(w, r, http.StatusInternalServerError, fmt.Sprintf("Cannot read the screenshot %s of template %s", fileName, templateIDString))
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	http.ServeContent(w, r, fileName, info.ModTime(), file)
+}
+
+//GetTemplateDefaultVersion is a handler returning the default version of a template, so that a client needs
+//not read the template first to learn which version is the default
+func GetTemplateDefaultVersion(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	templateIDString := vars["catalog_template_Id"]
+	requestLog(r).Debugf("GetTemplateDefaultVersion for template Id: %s", templateIDString)
+	pathTokens, err := splitTemplateID(templateIDString, 2, 2)
+	if err != nil {
+		requestLog(r).Debugf("Malformed template Id: %s, %v", templateIDString, err)
+		ReturnHTTPError(w, r, http.StatusBadRequest, fmt.Sprintf("Malformed template Id %s: %v", templateIDString, err))
+		return
+	}
+
+	templateMetadata, ok := manager.GetTemplateMetadata(pathTokens[0], pathTokens[1])
+	if !ok {
+		requestLog(r).Debugf("Cannot find template: %s", templateIDString)
+		ReturnHTTPError(w, r, http.StatusNotFound, fmt.Sprintf("Cannot find template: %s", templateIDString))
+		return
+	}
+	if templateMetadata.DefaultVersion == "" {
+		ReturnHTTPError(w, r, http.StatusNotFound, fmt.Sprintf("Template %s has no default version configured", templateIDString))
+		return
+	}
+	if _, ok := templateMetadata.VersionLinks[templateMetadata.DefaultVersion]; !ok {
+		ReturnHTTPError(w, r, http.StatusNotFound, fmt.Sprintf("Cannot find the default version %s of template %s", templateMetadata.DefaultVersion, templateIDString))
+		return
+	}
+	folder, _ := versionFolder(&templateMetadata, templateMetadata.DefaultVersion)
+	loadTemplateVersion(pathTokens[0], pathTokens[1], folder, w, r)
+}
+
+//GetTemplateCompatibility is a handler returning the constraints declared by every version of a template,
+//so that a version compatible with an environment can be picked at a glance
+func GetTemplateCompatibility(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	templateIDString := vars["catalog_template_Id"]
+	requestLog(r).Debugf("GetTemplateCompatibility for template Id: %s", templateIDString)
+	pathTokens, err := splitTemplateID(templateIDString, 2, 2)
+	if err != nil {
+		requestLog(r).Debugf("Malformed template Id: %s, %v", templateIDString, err)
+		ReturnHTTPError(w, r, http.StatusBadRequest, fmt.Sprintf("Malformed template Id %s: %v", templateIDString, err))
+		return
+	}
+
+	compatibility, ok := manager.GetCompatibility(requestLog(r), pathTokens[0], pathTokens[1])
+	if !ok {
+		requestLog(r).Debugf("Cannot find template: %s", templateIDString)
+		ReturnHTTPError(w, r, http.StatusNotFound, fmt.Sprintf("Cannot find template: %s", templateIDString))
+		return
+	}
+	compatibility.Links = map[string]string{
+		"self": URLEncoded(api.GetApiContext(r).UrlBuilder.Current()),
+	}
+	api.GetApiContext(r).Write(&compatibility)
+}
+
+//GetTemplateDiff is a handler returning the unified diff of the compose files of two versions of a template,
+//given by their version or their version folder
+func GetTemplateDiff(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	templateIDString := vars["catalog_template_Id"]
+	from := r.URL.Query().Get("from")
+	to := r.URL.Query().Get("to")
+	requestLog(r).Debugf("GetTemplateDiff for template Id: %s from version %s to version %s", templateIDString, from, to)
+	pathTokens, err := splitTemplateID(templateIDString, 2, 2)
+	if err != nil {
+		requestLog(r).Debugf("Malformed template Id: %s, %v", templateIDString, err)
+		ReturnHTTPError(w, r, http.StatusBadRequest, fmt.Sprintf("Malformed template Id %s: %v", templateIDString, err))
+		return
+	}
+	if from == "" || to == "" {
+		ReturnHTTPError(w, r, http.StatusBadRequest, "Query parameters from and to are required")
+		return
+	}
+	for _, version := range []string{from, to} {
+		if err := checkIDPart(version); err != nil {
+			ReturnHTTPError(w, r, http.StatusBadRequest, fmt.Sprintf("Malformed version %q: %v", version, err))
+			return
+		}
+	}
+	templateMetadata, ok := manager.GetTemplateMetadata(pathTokens[0], pathTokens[1])
+	if !ok {
+		requestLog(r).Debugf("Cannot find template: %s", templateIDString)
+		ReturnHTTPError(w, r, http.StatusNotFound, fmt.Sprintf("Cannot find template: %s", templateIDString))
+		return
+	}
+
+	var folders []string
+	var versions []*model.Template
+	for _, version := range []string{from, to} {
+		folder, ok := versionFolder(&templateMetadata, version)
+		if !ok {
+			ReturnHTTPError(w, r, http.StatusNotFound, fmt.Sprintf("Cannot find template version: %s:%s", templateIDString, version))
+			return
+		}
+		folders = append(folders, folder)
+		template, ok := manager.ReadTemplateVersionWithLogger(requestLog(r), pathTokens[0], pathTokens[1], folder)
+		if !ok {
+			ReturnHTTPError(w, r, http.StatusNotFound, fmt.Sprintf("Cannot find template version: %s:%s", templateIDString, folder))
+			return
+		}
+		versions = append(versions, template)
+	}
+
+	//diff the compose files of either version, in name order
+	composeFiles := make(map[string]bool)
+	for _, template := range versions {
+		for fileName := range template.Files {
+			if model.IsComposeFile(fileName) {
+				composeFiles[fileName] = true
+			}
+		}
+	}
+	var fileNames []string
+	for fileName := range composeFiles {
+		fileNames = append(fileNames, fileName)
+	}
+	sort.Strings(fileNames)
+
+	resp := model.TemplateDiff{
+		TemplateID:   templateIDString,
+		From:         from,
+		To:           to,
+		ChangedFiles: []string{},
+	}
+	resp.Type = "templateDiff"
+	for _, fileName := range fileNames {
+		fromContent, inFrom := versions[0].Files[fileName]
+		toContent, inTo := versions[1].Files[fileName]
+		fromName, toName := "a/"+folders[0]+"/"+fileName, "b/"+folders[1]+"/"+fileName
+		if !inFrom {
+			fromName = "/dev/null"
+		}
+		if !inTo {
+			toName = "/dev/null"
+		}
+		if diff := model.UnifiedDiff(fromName, toName, fromContent, toContent); diff != "" {
+			resp.ChangedFiles = append(resp.ChangedFiles, fileName)
+			resp.Diff += diff
+		}
+	}
+	api.GetApiContext(r).Write(&resp)
+}
+
+//maxBatchVersions is the number of versions a batch request may ask for
+const maxBatchVersions = 50
+
+//BatchTemplateVersions is a handler returning the versions of a template listed in the posted JSON array,
+//read in parallel, so that a client comparing versions needs a single request
+func BatchTemplateVersions(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	templateIDString := vars["catalog_template_Id"]
+	requestLog(r).Debugf("BatchTemplateVersions for template Id: %s", templateIDString)
+	pathTokens, err := splitTemplateID(templateIDString, 2, 2)
+	if err != nil {
+		requestLog(r).Debugf("Malformed template Id: %s, %v", templateIDString, err)
+		ReturnHTTPError(w, r, http.StatusBadRequest, fmt.Sprintf("Malformed template Id %s: %v", templateIDString, err))
+		return
+	}
+
+	var versions []string
+	if err := json.NewDecoder(r.Body).Decode(&versions); err != nil {
+		ReturnHTTPError(w, r, http.StatusBadRequest, fmt.Sprintf("Versions must be a JSON array of strings: %v", err))
+		return
+	}
+	if len(versions) > maxBatchVersions {
+		ReturnHTTPError(w, r, http.StatusBadRequest, fmt.Sprintf("At most %d versions can be read at once", maxBatchVersions))
+		return
+	}
+	for _, version := range versions {
+		if err := checkIDPart(version); err != nil {
+			ReturnHTTPError(w, r, http.StatusBadRequest, fmt.Sprintf("Malformed version %q: %v", version, err))
+			return
+		}
+	}
+	templateMetadata, ok := manager.GetTemplateMetadata(pathTokens[0], pathTokens[1])
+	if !ok {
+		requestLog(r).Debugf("Cannot find template: %s", templateIDString)
+		ReturnHTTPError(w, r, http.StatusNotFound, fmt.Sprintf("Cannot find template: %s", templateIDString))
+		return
+	}
+
+	batch := model.TemplateVersionBatch{}
+	batch.Type = "templateVersionBatch"
+	batch.TemplateID = templateMetadata.Id
+	batch.Versions = make(map[string]model.Template)
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+	seen := make(map[string]bool)
+	for _, version := range versions {
+		if seen[version] {
+			continue
+		}
+		seen[version] = true
+		//only the versions of the template are read, a version that is none is missing
+		folder, ok := versionFolder(&templateMetadata, version)
+		if !ok {
+			mutex.Lock()
+			batch.Missing = append(batch.Missing, version)
+			mutex.Unlock()
+			continue
+		}
+		wg.Add(1)
+		go func(version string, folder string) {
+			defer wg.Done()
+			template, ok := manager.ReadTemplateVersionWithLogger(requestLog(r), pathTokens[0], pathTokens[1], folder)
+			mutex.Lock()
+			defer mutex.Unlock()
+			if !ok {
+				batch.Missing = append(batch.Missing, version)
+				return
+			}
+			batch.Versions[version] = *template
+		}(version, folder)
+	}
+	wg.Wait()
+
+	for version, template := range batch.Versions {
+		template.Type = "templateVersion"
+		template.VersionLinks = PopulateTemplateLinks(r, &template)
+		rewriteImages(r, &template)
+		omitUnrequestedFields(r, &template)
+		batch.Versions[version] = template
+	}
+	sort.Strings(batch.Missing)
+	api.GetApiContext(r).Write(&batch)
+}
+
+//versionFolder returns the folder of the given version of the template, the version may also name a version
+//folder of the template; false if the template has no such version
+func versionFolder(template *model.Template, version string) (string, bool) {
+	if link, ok := template.VersionLinks[version]; ok {
+		return linkFolder(link), true
+	}
+	for _, link := range template.VersionLinks {
+		if linkFolder(link) == version {
+			return version, true
+		}
+	}
+	return "", false
+}
+
+func linkFolder(link string) string {
+	tokens := strings.Split(link, ":")
+	return tokens[len(tokens)-1]
+}
+
+//ValidateTemplateAnswers is a handler checking the answers posted as a JSON object against
+//the validation constraints of the questions of a template version
+func ValidateTemplateAnswers(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	templateIDString := vars["catalog_template_version_Id"]
+	requestLog(r).Debugf("ValidateTemplateAnswers for template Id: %s", templateIDString)
+	pathTokens, err := splitTemplateID(templateIDString, 3, 3)
+	if err != nil {
+		requestLog(r).Debugf("Malformed template version Id: %s, %v", templateIDString, err)
+		ReturnHTTPError(w, r, http.StatusBadRequest, fmt.Sprintf("Malformed template version Id %s: %v", templateIDString, err))
+		return
+	}
+
+	template, ok := manager.ReadTemplateVersionWithLogger(requestLog(r), pathTokens[0], pathTokens[1], pathTokens[2])
+	if !ok {
+		requestLog(r).Debugf("Cannot find template version: %s", templateIDString)
+		ReturnHTTPError(w, r, http.StatusNotFound, fmt.Sprintf("Cannot find template version: %s", templateIDString))
+		return
+	}
+
+	answers, err := readAnswers(r)
+	if err != nil {
+		ReturnHTTPError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	validation := model.AnswersValidation{}
+	validation.Type = "answersValidation"
+	validation.Errors = model.ValidateAnswers(template.Questions, answers)
+	validation.Valid = len(validation.Errors) == 0
+	api.GetApiContext(r).Write(&validation)
+}
+
+//RenderTemplateVersion is a handler returning the compose files of a template version with the placeholders
+//substituted by the posted answers, or by the defaults of the questions left unanswered
+func RenderTemplateVersion(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	templateIDString := vars["catalog_template_version_Id"]
+	requestLog(r).Debugf("RenderTemplateVersion for template Id: %s", templateIDString)
+	pathTokens, err := splitTemplateID(templateIDString, 3, 3)
+	if err != nil {
+		requestLog(r).Debugf("Malformed template version Id: %s, %v", templateIDString, err)
+		ReturnHTTPError(w, r, http.StatusBadRequest, fmt.Sprintf("Malformed template version Id %s: %v", templateIDString, err))
+		return
+	}
+
+	template, ok := manager.ReadTemplateVersionWithLogger(requestLog(r), pathTokens[0], pathTokens[1], pathTokens[2])
+	if !ok {
+		requestLog(r).Debugf("Cannot find template version: %s", templateIDString)
+		ReturnHTTPError(w, r, http.StatusNotFound, fmt.Sprintf("Cannot find template version: %s", templateIDString))
+		return
+	}
+
+	answers, err := readAnswers(r)
+	if err != nil {
+		ReturnHTTPError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	values := model.AnswerValues(template.Questions, answers)
+	if errors := model.ValidateAnswers(template.Questions, answers); len(errors) > 0 {
+		ReturnHTTPError(w, r, http.StatusBadRequest, "Invalid answers: "+strings.Join(errors, ", "))
+		return
+	}
+
+	rendered := model.RenderedTemplate{
+		TemplateVersionID: template.Id,
+		Files:             make(map[string]string),
+	}
+	rendered.Type = "renderedTemplate"
+	//the images are rewritten once interpolated, so that the images given by answers are rewritten too
+	raw := rawCompose(r)
+	for fileName, content := range template.Files {
+		if model.IsComposeFile(fileName) {
+			content = model.InterpolateCompose(content, values)
+			if !raw {
+				content = manager.RewriteComposeImages(fileName, content)
+			}
+			rendered.Files[fileName] = content
+		}
+	}
+	api.GetApiContext(r).Write(&rendered)
+}
+
+//GetTemplateDefaultAnswers is a handler returning an answers file giving every question of a template version
+//its default, so that tooling can seed the answers of a deploy without reading the questions
+func GetTemplateDefaultAnswers(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	templateIDString := vars["catalog_template_version_Id"]
+	requestLog(r).Debugf("GetTemplateDefaultAnswers for template Id: %s", templateIDString)
+	pathTokens, err := splitTemplateID(templateIDString, 3, 3)
+	if err != nil {
+		requestLog(r).Debugf("Malformed template version Id: %s, %v", templateIDString, err)
+		ReturnHTTPError(w, r, http.StatusBadRequest, fmt.Sprintf("Malformed template version Id %s: %v", templateIDString, err))
+		return
+	}
+
+	template, ok := manager.ReadTemplateVersionWithLogger(requestLog(r), pathTokens[0], pathTokens[1], pathTokens[2])
+	if !ok {
+		requestLog(r).Debugf("Cannot find template version: %s", templateIDString)
+		ReturnHTTPError(w, r, http.StatusNotFound, fmt.Sprintf("Cannot find template version: %s", templateIDString))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Write([]byte(model.DefaultAnswersFile(template.Questions)))
+}
+
+//readAnswers reads the JSON object of answers posted to validate or render a template version, numbers and
+//booleans are read as the strings they are given as in compose files
+func readAnswers(r *http.Request) (map[string]string, error) {
+	var rawAnswers map[string]interface{}
+	if err := json.NewDecoder(r.Body).Decode(&rawAnswers); err != nil {
+		return nil, fmt.Errorf("Answers must be a JSON object: %v", err)
+	}
+	answers := make(map[string]string)
+	for variable, value := range rawAnswers {
+		switch typedValue := value.(type) {
+		case nil:
+		case float64:
+			//print JSON numbers without an exponent
+			answers[variable] = strconv.FormatFloat(typedValue, 'f', -1, 64)
+		default:
+			answers[variable] = fmt.Sprint(typedValue)
+		}
+	}
+	return answers, nil
+}
+
 //loadTemplateMetadata returns template metadata for the provided templateId
 func loadTemplateMetadata(catalogID string, templateID string, w http.ResponseWriter, r *http.Request) {
 	path := catalogID + "/" + templateID
 	tempID := catalogID + ":" + templateID
-	log.Debugf("Request to load metadata for template: %s", path)
+	requestLog(r).Debugf("Request to load metadata for template: %s", path)
 	rancherVersion := r.URL.Query().Get("minimumRancherVersion_lte")
 	if rancherVersion != "" {
-		log.Debugf("only versions with minimumRancherVersion <= %s", rancherVersion)
+		requestLog(r).Debugf("only versions with minimumRancherVersion <= %s", rancherVersion)
 	}
 	rancherVersionGte := r.URL.Query().Get("maximumRancherVersion_gte")
 	if rancherVersionGte != "" {
-		log.Debugf("only versions with maximumRancherVersion >= %s", rancherVersionGte)
+		requestLog(r).Debugf("only versions with maximumRancherVersion >= %s", rancherVersionGte)
 	}
 	templateMetadata, ok := manager.GetTemplateMetadata(catalogID, templateID)
 	if ok {
 		if rancherVersion != "" {
 			var err error
-			templateMetadata.VersionLinks, err = filterByMinimumRancherVersion(rancherVersion, &templateMetadata)
+			templateMetadata.VersionLinks, err = filterByMinimumRancherVersion(requestLog(r), rancherVersion, &templateMetadata)
 			if err != nil {
-				log.Debugf("Cannot apply the minimumRancherVersion_lte filter for template: %s", path)
+				requestLog(r).Debugf("Cannot apply the minimumRancherVersion_lte filter for template: %s", path)
 				ReturnHTTPError(w, r, http.StatusNotFound, fmt.Sprintf("Cannot apply the minimumRancherVersion_lte filter for template: %s", tempID))
 			}
 		}
 
 		if rancherVersionGte != "" {
 			var err error
-			templateMetadata.VersionLinks, err = filterByMaximumRancherVersion(rancherVersionGte, &templateMetadata)
+			templateMetadata.VersionLinks, err = filterByMaximumRancherVersion(requestLog(r), rancherVersionGte, &templateMetadata)
 			if err != nil {
-				log.Debugf("Cannot apply the maximumRancherVersion_gte filter for template: %s", path)
+				requestLog(r).Debugf("Cannot apply the maximumRancherVersion_gte filter for template: %s", path)
 				ReturnHTTPError(w, r, http.StatusNotFound, fmt.Sprintf("Cannot apply the maximumRancherVersion_gte filter for template: %s", tempID))
 			}
 		}
+		includeYanked, err := getBoolFilter(r, "includeYanked")
+		if err != nil {
+			ReturnHTTPError(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+		if includeYanked == nil || !*includeYanked {
+			templateMetadata.VersionLinks = withoutYankedVersions(&templateMetadata)
+		}
+		templateMetadata.VersionCount = len(templateMetadata.VersionLinks)
 		PopulateTemplateLinks(r, &templateMetadata)
 		api.GetApiContext(r).Write(&templateMetadata)
 	} else {
-		log.Debugf("Cannot find metadata for template: %s", path)
+		requestLog(r).Debugf("Cannot find metadata for template: %s", path)
 		ReturnHTTPError(w, r, http.StatusNotFound, fmt.Sprintf("Cannot find metadata for template: %s", tempID))
 	}
 }
 
 func callToRead(catalogID string, templateID string, versionID string, w http.ResponseWriter, r *http.Request) {
 	path := catalogID + "/" + templateID + "/" + versionID
-	log.Debugf("Request to load  template version: %s", path)
+	requestLog(r).Debugf("Request to load  template version: %s", path)
 	rancherVersion := r.URL.Query().Get("minimumRancherVersion_lte")
 	if rancherVersion != "" {
-		log.Debugf("and if minimumRancherVersion <= %s", rancherVersion)
+		requestLog(r).Debugf("and if minimumRancherVersion <= %s", rancherVersion)
 	}
 	rancherVersionGte := r.URL.Query().Get("maximumRancherVersion_gte")
 	if rancherVersionGte != "" {
-		log.Debugf("and if maximumRancherVersion >= %s", rancherVersionGte)
+		requestLog(r).Debugf("and if maximumRancherVersion >= %s", rancherVersionGte)
 	}
 
-	manager.ReadTemplateVersion(catalogID, templateID, versionID)
+	manager.ReadTemplateVersionWithLogger(requestLog(r), catalogID, templateID, versionID)
 }
 
 //loadTemplateVersion returns template version details for the provided templateId/versionId
@@ -436,55 +1329,139 @@ func loadTemplateVersion(catalogID string, templateID string, versionID string,
 	//read the template version from disk
 	tempVersionID := catalogID + ":" + templateID + ":" + versionID
 	path := catalogID + "/" + templateID + "/" + versionID
-	log.Debugf("Request to load  template version: %s", path)
+	requestLog(r).Debugf("Request to load  template version: %s", path)
 	rancherVersion := r.URL.Query().Get("minimumRancherVersion_lte")
 	if rancherVersion != "" {
-		log.Debugf("and if minimumRancherVersion <= %s", rancherVersion)
+		requestLog(r).Debugf("and if minimumRancherVersion <= %s", rancherVersion)
 	}
 	rancherVersionGte := r.URL.Query().Get("maximumRancherVersion_gte")
 	if rancherVersionGte != "" {
-		log.Debugf("and if maximumRancherVersion >= %s", rancherVersionGte)
+		requestLog(r).Debugf("and if maximumRancherVersion >= %s", rancherVersionGte)
 	}
 
-	template, ok := manager.ReadTemplateVersion(catalogID, templateID, versionID)
+	template, ok := manager.ReadTemplateVersionWithLogger(requestLog(r), catalogID, templateID, versionID)
 	if ok {
 		template.Type = "templateVersion"
 		template.VersionLinks = PopulateTemplateLinks(r, template)
 		upgradeInfo := GetUpgradeInfo(r, template.Path)
 		template.UpgradeVersionLinks = upgradeInfo.NewVersionLinks
+		rewriteImages(r, template)
+		omitUnrequestedFields(r, template)
 		api.GetApiContext(r).Write(&template)
 	} else {
-		log.Debugf("Cannot find template: %s", path)
+		requestLog(r).Debugf("Cannot find template: %s", path)
 		ReturnHTTPError(w, r, http.StatusNotFound, fmt.Sprintf("Cannot find template: %s", tempVersionID))
 	}
 }
 
+//rawCompose tells if the raw query parameter asks for the compose files as written, without the registry rewrites
+func rawCompose(r *http.Request) bool {
+	raw, _ := strconv.ParseBool(r.URL.Query().Get("raw"))
+	return raw
+}
+
+//rewriteImages points the images of the compose files of a template version at the registry mirrors, unless the
+//compose files are asked for as written
+func rewriteImages(r *http.Request, template *model.Template) {
+	if !rawCompose(r) {
+		manager.RewriteImageRegistries(template)
+	}
+}
+
+//omitUnrequestedFields drops the heavy fields of a template version that are not listed in
+//the include query parameter, the compose files are dropped unless listed and the questions
+//and bindings are kept when the parameter is absent
+func omitUnrequestedFields(r *http.Request, template *model.Template) {
+	values, ok := r.URL.Query()["include"]
+	include := make(map[string]bool)
+	for _, value := range values {
+		for _, field := range strings.Split(value, ",") {
+			include[strings.TrimSpace(field)] = true
+		}
+	}
+
+	if !include["files"] && !include["compose"] {
+		template.Files = nil
+	}
+	if !ok {
+		return
+	}
+	if !include["questions"] {
+		template.Questions = nil
+	}
+	if !include["bindings"] {
+		template.Bindings = nil
+	}
+}
+
+//isImageRequest tells if the request asks for the template icon, a plain ?image or ?image&theme=<theme>
+func isImageRequest(r *http.Request) bool {
+	if r.URL.RawQuery == "" {
+		return false
+	}
+	if strings.EqualFold("image", r.URL.RawQuery) {
+		return true
+	}
+	_, ok := r.URL.Query()["image"]
+	return ok
+}
+
+//hasDarkImage tells if the template version, or the template it belongs to, has a dark theme icon
+func hasDarkImage(catalogID string, templateID string, versionID string) bool {
+	if _, ok := manager.PathToDarkImage.Get(catalogID + "/" + templateID + "/" + versionID); ok && versionID != "" {
+		return true
+	}
+	if _, ok := manager.PathToImage.Get(catalogID + "/" + templateID + "/" + versionID); ok && versionID != "" {
+		//the version has its own icon without a dark variant
+		return false
+	}
+	_, ok := manager.PathToDarkImage.Get(catalogID + "/" + templateID)
+	return ok
+}
+
 //loadFile loads the file under the catalog
-func loadFile(catalogID string, templateID string, versionID string, fileNameMap map[string]string, w http.ResponseWriter, r *http.Request) {
+func loadFile(catalogID string, templateID string, versionID string, fileNames *manager.FileNames, w http.ResponseWriter, r *http.Request) {
 	var fileID, path string
 
-	prefix, templateName := manager.ExtractTemplatePrefixAndName(templateID)
+	prefix, templateName := manager.TemplateFolder(catalogID, templateID)
 
 	if versionID != "" {
 		var ok bool
-		fileID, ok = fileNameMap[catalogID+"/"+templateID+"/"+versionID]
+		fileID, ok = fileNames.Get(catalogID + "/" + templateID + "/" + versionID)
 		if !ok {
-			fileID = fileNameMap[catalogID+"/"+templateID]
+			fileID, _ = fileNames.Get(catalogID + "/" + templateID)
 			path = "DATA/" + catalogID + "/" + prefix + "/" + templateName + "/" + fileID
 		} else {
 			path = "DATA/" + catalogID + "/" + prefix + "/" + templateName + "/" + versionID + "/" + fileID
 		}
 	} else {
-		fileID = fileNameMap[catalogID+"/"+templateID]
+		fileID, _ = fileNames.Get(catalogID + "/" + templateID)
 		path = "DATA/" + catalogID + "/" + prefix + "/" + templateName + "/" + fileID
 	}
-	log.Debugf("Request to load file: %s", path)
+	requestLog(r).Debugf("Request to load file: %s", path)
 	http.ServeFile(w, r, path)
 }
 
 //RefreshCatalog will be doing a force catalog refresh
 func RefreshCatalog(w http.ResponseWriter, r *http.Request) {
-	log.Infof("Request to refresh catalog")
+	if catalogID := mux.Vars(r)["catalogId"]; catalogID != "" {
+		refreshNamedCatalog(catalogID, w, r)
+		return
+	}
+	requestLog(r).Infof("Request to refresh catalog")
+
+	if inProgress := manager.RefreshesInProgress(); len(inProgress) > 0 {
+		//the catalogs in the middle of a refresh are refreshed again once it completes, the others right away,
+		//without reloading the config which would recreate the catalogs being refreshed
+		requestLog(r).Infof("Refresh already in progress for %d catalogs, refreshing them again once it completes", len(inProgress))
+		manager.RefreshAllCatalogs()
+		resp := model.RefreshStatusCollection{}
+		resp.Data = manager.RefreshesInProgress()
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		api.GetApiContext(r).Write(&resp)
+		return
+	}
 
 	//Reload catalog
 	manager.SetEnv()
@@ -495,45 +1472,252 @@ func RefreshCatalog(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 }
 
+//refreshNamedCatalog pulls and walks only the given catalog, leaving the other catalogs untouched
+func refreshNamedCatalog(catalogID string, w http.ResponseWriter, r *http.Request) {
+	requestLog(r).Infof("Request to refresh catalog %s", catalogID)
+
+	inProgress := false
+	for _, status := range manager.RefreshesInProgress() {
+		if status.CatalogID == catalogID {
+			inProgress = true
+		}
+	}
+
+	//a refresh requested while one is in progress returns at once and schedules the follow-up refresh
+	if !manager.RefreshCatalog(catalogID) {
+		requestLog(r).Debugf("Cannot find catalog by catalogID: %s", catalogID)
+		ReturnHTTPError(w, r, http.StatusNotFound, fmt.Sprintf("Cannot find catalog by catalogID: %s", catalogID))
+		return
+	}
+	if inProgress {
+		requestLog(r).Infof("Refresh already in progress for catalog %s, refreshing it again once it completes", catalogID)
+		resp := model.RefreshStatusCollection{}
+		for _, status := range manager.RefreshesInProgress() {
+			if status.CatalogID == catalogID {
+				resp.Data = append(resp.Data, status)
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		api.GetApiContext(r).Write(&resp)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+//RecloneCatalogs is a handler for route /admin/reclone, it clones all the catalogs again from scratch
+func RecloneCatalogs(w http.ResponseWriter, r *http.Request) {
+	requestLog(r).Infof("Request to reclone the catalogs")
+
+	statuses, err := manager.RecloneAllCatalogs()
+	if err == manager.ErrRefreshInProgress {
+		requestLog(r).Infof("Refresh already in progress, skipping the reclone")
+		resp := model.RefreshStatusCollection{}
+		resp.Data = manager.RefreshesInProgress()
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		api.GetApiContext(r).Write(&resp)
+		return
+	} else if err != nil {
+		requestLog(r).Errorf("Error recloning the catalogs: %v", err)
+		ReturnHTTPError(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	resp := model.RecloneStatusCollection{}
+	resp.Data = statuses
+	api.GetApiContext(r).Write(&resp)
+}
+
+//ListDiagnostics is a handler for route /admin/diagnostics and returns the problems found while loading the templates
+func ListDiagnostics(w http.ResponseWriter, r *http.Request) {
+	requestLog(r).Debugf("Request to list the template diagnostics")
+	resp := model.TemplateDiagnosticCollection{}
+	resp.Data = manager.ListDiagnostics()
+	api.GetApiContext(r).Write(&resp)
+}
+
+//ListQuestionUsages is a handler for route /admin/questions and returns the distinct question variables asked by
+//the default versions of the templates, with their types and the templates asking them
+func ListQuestionUsages(w http.ResponseWriter, r *http.Request) {
+	requestLog(r).Debugf("Request to list the question usages")
+	resp := model.QuestionUsageCollection{}
+	resp.Data = manager.ListQuestionUsages(requestLog(r))
+	api.GetApiContext(r).Write(&resp)
+}
+
+//SelfTest is a handler for route /admin/selftest and reads the default version of the first template end to
+//end, it answers 503 with the report if any check fails
+func SelfTest(w http.ResponseWriter, r *http.Request) {
+	requestLog(r).Debugf("Request to run the self test")
+	report := manager.SelfTest(requestLog(r))
+	if !report.Passed {
+		requestLog(r).Warnf("The self test of %s failed: %v", report.TemplateVersionID, report.Checks)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	api.GetApiContext(r).Write(&report)
+}
+
+//GetTemplateLastCommit is a handler returning the last git commit that modified a template or template version
+func GetTemplateLastCommit(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	templateIDString := vars["catalog_template_version_Id"]
+	requestLog(r).Debugf("Request to get the last commit of template Id: %s", templateIDString)
+	pathTokens, err := splitTemplateID(templateIDString, 2, 3)
+	if err != nil {
+		ReturnHTTPError(w, r, http.StatusBadRequest, fmt.Sprintf("Malformed template Id %s: %v", templateIDString, err))
+		return
+	}
+
+	var versionID string
+	if len(pathTokens) == 3 {
+		versionID = pathTokens[2]
+	}
+
+	commit, ok, err := manager.GetLastCommit(pathTokens[0], pathTokens[1], versionID)
+	if err != nil {
+		requestLog(r).Errorf("Error getting the last commit of template %s, error: %v", templateIDString, err)
+		ReturnHTTPError(w, r, http.StatusInternalServerError, fmt.Sprintf("Cannot read the last commit of template: %s", templateIDString))
+		return
+	}
+	if !ok {
+		ReturnHTTPError(w, r, http.StatusNotFound, fmt.Sprintf("Cannot find template: %s", templateIDString))
+		return
+	}
+	commit.Type = "templateCommit"
+	commit.TemplateID = templateIDString
+	api.GetApiContext(r).Write(&commit)
+}
+
+const (
+	//defaultHistoryLimit is the number of commits in a page of history unless the limit parameter says otherwise
+	defaultHistoryLimit = 20
+	//maxHistoryLimit is the largest page of history served
+	maxHistoryLimit = 100
+)
+
+//GetTemplateHistory is a handler returning the commits that modified a template or template version, newest
+//first, a page of limit commits at a time starting at the marker, which is the number of newer commits skipped
+func GetTemplateHistory(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	templateIDString := vars["catalog_template_version_Id"]
+	requestLog(r).Debugf("Request to get the history of template Id: %s", templateIDString)
+	pathTokens, err := splitTemplateID(templateIDString, 2, 3)
+	if err != nil {
+		ReturnHTTPError(w, r, http.StatusBadRequest, fmt.Sprintf("Malformed template Id %s: %v", templateIDString, err))
+		return
+	}
+
+	var versionID string
+	if len(pathTokens) == 3 {
+		versionID = pathTokens[2]
+	}
+
+	limit, err := getIntFilter(r, "limit")
+	if err != nil || limit == 0 || limit > maxHistoryLimit {
+		ReturnHTTPError(w, r, http.StatusBadRequest, fmt.Sprintf("Invalid limit, it must be between 1 and %d", maxHistoryLimit))
+		return
+	} else if limit == -1 {
+		limit = defaultHistoryLimit
+	}
+	skip, err := getIntFilter(r, "marker")
+	if err != nil {
+		ReturnHTTPError(w, r, http.StatusBadRequest, err.Error())
+		return
+	} else if skip == -1 {
+		skip = 0
+	}
+
+	commits, ok, more, err := manager.GetHistory(pathTokens[0], pathTokens[1], versionID, skip, limit)
+	if err != nil {
+		requestLog(r).Errorf("Error getting the history of template %s, error: %v", templateIDString, err)
+		ReturnHTTPError(w, r, http.StatusInternalServerError, fmt.Sprintf("Cannot read the history of template: %s", templateIDString))
+		return
+	}
+	if !ok {
+		ReturnHTTPError(w, r, http.StatusNotFound, fmt.Sprintf("Cannot find template: %s", templateIDString))
+		return
+	}
+
+	resp := model.TemplateCommitCollection{}
+	resp.ResourceType = "templateCommit"
+	pageLimit := int64(limit)
+	resp.Pagination = &client.Pagination{
+		Marker: strconv.Itoa(skip),
+		Limit:  &pageLimit,
+	}
+	if more {
+		resp.Pagination.Next = pageLink(r, skip+limit, limit)
+	}
+	if skip > 0 {
+		previous := skip - limit
+		if previous < 0 {
+			previous = 0
+		}
+		resp.Pagination.Previous = pageLink(r, previous, limit)
+	}
+	for _, commit := range commits {
+		commit.Type = "templateCommit"
+		commit.TemplateID = templateIDString
+		resp.Data = append(resp.Data, commit)
+	}
+	api.GetApiContext(r).Write(&resp)
+}
+
+//pageLink returns the link to the page of the current request starting at the given marker
+func pageLink(r *http.Request, marker int, limit int) string {
+	pageURL, err := url.Parse(api.GetApiContext(r).UrlBuilder.Current())
+	if err != nil {
+		requestURL := *r.URL
+		pageURL = &requestURL
+	}
+	query := pageURL.Query()
+	query.Set("marker", strconv.Itoa(marker))
+	query.Set("limit", strconv.Itoa(limit))
+	pageURL.RawQuery = query.Encode()
+	return pageURL.String()
+}
+
 //GetUpgradeInfo returns if any new versions are available for the given template uuid
 func GetUpgradeInfo(r *http.Request, path string) model.UpgradeInfo {
 	var upgradeInfo model.UpgradeInfo
-	log.Debugf("Request to get new template versions available for upgrade, for path %s", path)
+	requestLog(r).Debugf("Request to get new template versions available for upgrade, for path %s", path)
 	rancherVersion := r.URL.Query().Get("minimumRancherVersion_lte")
 	if rancherVersion != "" {
-		log.Debugf("and with minimumRancherVersion <= %s", rancherVersion)
+		requestLog(r).Debugf("and with minimumRancherVersion <= %s", rancherVersion)
 	}
 	rancherVersionGte := r.URL.Query().Get("maximumRancherVersion_gte")
 	if rancherVersionGte != "" {
-		log.Debugf("and if maximumRancherVersion >= %s", rancherVersionGte)
+		requestLog(r).Debugf("and if maximumRancherVersion >= %s", rancherVersionGte)
 	}
 
 	templateMetadata, ok := manager.GetNewTemplateVersions(path)
 	if ok {
 		if rancherVersion != "" {
 			var err error
-			templateMetadata.VersionLinks, err = filterByMinimumRancherVersion(rancherVersion, &templateMetadata)
+			templateMetadata.VersionLinks, err = filterByMinimumRancherVersion(requestLog(r), rancherVersion, &templateMetadata)
 			if err != nil {
-				log.Debugf("Cannot provide upgradeInfo as cannot apply the minimumRancherVersion_lte filter for template: %s", path)
+				requestLog(r).Debugf("Cannot provide upgradeInfo as cannot apply the minimumRancherVersion_lte filter for template: %s", path)
 				return upgradeInfo
 			}
 		}
 		if rancherVersionGte != "" {
 			var err error
-			templateMetadata.VersionLinks, err = filterByMaximumRancherVersion(rancherVersionGte, &templateMetadata)
+			templateMetadata.VersionLinks, err = filterByMaximumRancherVersion(requestLog(r), rancherVersionGte, &templateMetadata)
 			if err != nil {
-				log.Debugf("Cannot provide upgradeInfo as cannot apply the maximumRancherVersion_gte filter for template: %s", path)
+				requestLog(r).Debugf("Cannot provide upgradeInfo as cannot apply the maximumRancherVersion_gte filter for template: %s", path)
 				return upgradeInfo
 			}
 		}
-		log.Debugf("Template returned by path: %v", templateMetadata.VersionLinks)
-		log.Debugf("Found Template: %s", templateMetadata.Name)
+		requestLog(r).Debugf("Template returned by path: %v", templateMetadata.VersionLinks)
+		requestLog(r).Debugf("Found Template: %s", templateMetadata.Name)
 		upgradeInfo.CurrentVersion = templateMetadata.Version
 
 		upgradeInfo.NewVersionLinks = make(map[string]string)
 		upgradeInfo.NewVersionLinks = PopulateTemplateLinks(r, &templateMetadata)
 	} else {
-		log.Debugf("Cannot provide upgradeInfo as, cannot find metadata for template path: %s", path)
+		requestLog(r).Debugf("Cannot provide upgradeInfo as, cannot find metadata for template path: %s", path)
 	}
 
 	return upgradeInfo
@@ -551,8 +1735,32 @@ func PopulateTemplateLinks(r *http.Request, template *model.Template) map[string
 	for key, value := range template.VersionLinks {
 		copyOfversionLinks[key] = URLEncoded(apiContext.UrlBuilder.ReferenceByIdLink("template", value))
 	}
+	//keep the ordered versions that passed the filters
+	var versions []string
+	for _, version := range template.Versions {
+		if _, ok := template.VersionLinks[version]; ok {
+			versions = append(versions, version)
+		}
+	}
+	template.Versions = versions
 
-	template.Links["icon"] = URLEncoded(apiContext.UrlBuilder.ReferenceByIdLink("template", template.IconLink))
+	if strings.HasPrefix(template.IconLink, "http://") || strings.HasPrefix(template.IconLink, "https://") {
+		//icons of charts from a Helm index are absolute URLs
+		template.Links["icon"] = template.IconLink
+	} else {
+		template.Links["icon"] = URLEncoded(apiContext.UrlBuilder.ReferenceByIdLink("template", template.IconLink))
+	}
+	if template.IconLinkDark != "" {
+		template.Links["iconDark"] = URLEncoded(apiContext.UrlBuilder.ReferenceByIdLink("template", template.IconLinkDark))
+	}
+	if len(template.Screenshots) > 0 {
+		//the screenshots are shared with the cached template, their links are written to a copy
+		screenshots := make([]string, len(template.Screenshots))
+		for i, screenshot := range template.Screenshots {
+			screenshots[i] = URLEncoded(apiContext.UrlBuilder.ReferenceByIdLink("template", screenshot))
+		}
+		template.Screenshots = screenshots
+	}
 	if template.ReadmeLink != "" {
 		template.Links["readme"] = URLEncoded(apiContext.UrlBuilder.ReferenceByIdLink("template", template.ReadmeLink))
 	}
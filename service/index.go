@@ -0,0 +1,75 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/rancher/go-rancher/api"
+	"github.com/rancher/go-rancher/client"
+	"github.com/rancher/rancher-catalog-service/manager"
+)
+
+//frameworkEndpoints are the endpoints served outside of the application routes
+var frameworkEndpoints = []endpoint{
+	{"GetAPIVersion", "GET", "/v1-catalog"},
+	{"ListSchemas", "GET", "/v1-catalog/schemas"},
+	{"GetSchema", "GET", "/v1-catalog/schemas/{id}"},
+	{"Metrics", "GET", "/metrics"},
+	{"Readiness", "GET", "/readiness"},
+	{"GetOpenAPI", "GET", "/v1-catalog/openapi.json"},
+}
+
+//endpoint describes a route of the service in the index
+type endpoint struct {
+	Name   string `json:"name"`
+	Method string `json:"method"`
+	Path   string `json:"path"`
+}
+
+//index is the response of the service root, the collection of api versions along with the version of the
+//service and the endpoints it serves
+type index struct {
+	client.GenericCollection
+	Version   string     `json:"version"`
+	Endpoints []endpoint `json:"endpoints"`
+}
+
+//GetIndex is a handler for route / and returns the api versions, the service version and the endpoints served,
+//so that it tells at a glance whether the service is up and what api it offers
+func GetIndex(w http.ResponseWriter, r *http.Request) {
+	apiContext := api.GetApiContext(r)
+
+	resp := index{
+		GenericCollection: client.GenericCollection{
+			Collection: client.Collection{
+				Type:         "collection",
+				ResourceType: "apiVersion",
+				Links: map[string]string{
+					"self":   apiContext.UrlBuilder.Current(),
+					"latest": apiContext.UrlBuilder.Version("v1-catalog"),
+				},
+			},
+			Data: []interface{}{
+				client.Resource{
+					Id:    "v1-catalog",
+					Type:  "apiVersion",
+					Links: map[string]string{"self": apiContext.UrlBuilder.Version("v1-catalog")},
+				},
+			},
+		},
+		Version:   manager.Version,
+		Endpoints: append([]endpoint{}, frameworkEndpoints...),
+	}
+	for _, route := range routes {
+		routePath := route.Pattern
+		if action, ok := actionRoutes[route.Name]; ok {
+			routePath += "?action=" + action
+		}
+		resp.Endpoints = append(resp.Endpoints, endpoint{route.Name, route.Method, routePath})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(&resp); err != nil {
+		requestLog(r).Errorf("Error writing the service index: %v", err)
+	}
+}
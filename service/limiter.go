@@ -0,0 +1,33 @@
+package service
+
+import (
+	"net/http"
+	"sync/atomic"
+
+	"github.com/rancher/rancher-catalog-service/manager"
+)
+
+//retryAfterSeconds is the delay suggested to clients turned away by the in-flight limiter
+const retryAfterSeconds string = "1"
+
+var inFlightReads int32
+
+//limitInFlight wraps the handlers that read template content from disk and turns away
+//requests with 503 when more than maxInFlightReads of them are already being served
+func limitInFlight(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if *manager.MaxInFlightReads <= 0 {
+			handler(w, r)
+			return
+		}
+
+		defer atomic.AddInt32(&inFlightReads, -1)
+		if atomic.AddInt32(&inFlightReads, 1) > int32(*manager.MaxInFlightReads) {
+			requestLog(r).Debugf("Too many requests in flight, rejecting request %s", r.URL.Path)
+			w.Header().Set("Retry-After", retryAfterSeconds)
+			ReturnHTTPError(w, r, http.StatusServiceUnavailable, "Too many requests in flight, retry later")
+			return
+		}
+		handler(w, r)
+	}
+}
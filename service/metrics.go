@@ -0,0 +1,156 @@
+package service
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/rancher/rancher-catalog-service/manager"
+)
+
+//metricsContentType is the content type of the Prometheus text exposition format
+const metricsContentType string = "text/plain; version=0.0.4"
+
+//unmatchedRoute labels the requests that do not match any route
+const unmatchedRoute string = "unmatched"
+
+//latencyBuckets are the upper bounds in seconds of the request latency histogram buckets
+var latencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+type routeLatency struct {
+	bucketCounts []uint64
+	count        uint64
+	sum          float64
+}
+
+type requestKey struct {
+	route string
+	code  int
+}
+
+var (
+	metricsLock    sync.Mutex
+	requestCounts  = make(map[requestKey]uint64)
+	requestLatency = make(map[string]*routeLatency)
+	metricsStarted = time.Now()
+)
+
+//statusRecorder remembers the status code written to the response
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (s *statusRecorder) WriteHeader(status int) {
+	s.status = status
+	s.ResponseWriter.WriteHeader(status)
+}
+
+//measureRequest serves the request through the handler and records its status code and latency by route name
+func measureRequest(router *mux.Router, handler http.Handler, w http.ResponseWriter, r *http.Request) {
+	route := unmatchedRoute
+	var match mux.RouteMatch
+	if router.Match(r, &match) && match.Route.GetName() != "" {
+		route = match.Route.GetName()
+	}
+
+	recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+	started := time.Now()
+	handler.ServeHTTP(recorder, r)
+	recordRequest(route, recorder.status, time.Since(started))
+}
+
+func recordRequest(route string, status int, duration time.Duration) {
+	metricsLock.Lock()
+	defer metricsLock.Unlock()
+
+	requestCounts[requestKey{route, status}]++
+
+	latency, ok := requestLatency[route]
+	if !ok {
+		latency = &routeLatency{bucketCounts: make([]uint64, len(latencyBuckets))}
+		requestLatency[route] = latency
+	}
+	seconds := duration.Seconds()
+	for i, bound := range latencyBuckets {
+		if seconds <= bound {
+			latency.bucketCounts[i]++
+		}
+	}
+	latency.count++
+	latency.sum += seconds
+}
+
+//WriteMetrics is a handler for route /metrics and returns the HTTP request metrics in the Prometheus text format
+func WriteMetrics(w http.ResponseWriter, r *http.Request) {
+	metricsLock.Lock()
+	defer metricsLock.Unlock()
+
+	w.Header().Set("Content-Type", metricsContentType)
+
+	fmt.Fprintln(w, "# HELP catalog_uptime_seconds Seconds since the catalog service started.")
+	fmt.Fprintln(w, "# TYPE catalog_uptime_seconds gauge")
+	fmt.Fprintf(w, "catalog_uptime_seconds %s\n", formatFloat(time.Since(metricsStarted).Seconds()))
+
+	keys := make([]requestKey, 0, len(requestCounts))
+	for key := range requestCounts {
+		keys = append(keys, key)
+	}
+	sort.Sort(requestKeys(keys))
+
+	fmt.Fprintln(w, "# HELP catalog_http_requests_total Number of HTTP requests by route and status code.")
+	fmt.Fprintln(w, "# TYPE catalog_http_requests_total counter")
+	for _, key := range keys {
+		fmt.Fprintf(w, "catalog_http_requests_total{route=%q,code=\"%d\"} %d\n", key.route, key.code, requestCounts[key])
+	}
+
+	fmt.Fprintln(w, "# HELP catalog_rate_limit_remaining Requests left in the rate limit last reported by the APIs the service requested, such as GitHub's.")
+	fmt.Fprintln(w, "# TYPE catalog_rate_limit_remaining gauge")
+	rateLimits := manager.GetRateLimits()
+	for _, limit := range rateLimits {
+		fmt.Fprintf(w, "catalog_rate_limit_remaining{host=%q} %d\n", limit.Host, limit.Remaining)
+	}
+	fmt.Fprintln(w, "# HELP catalog_rate_limit_reset_timestamp_seconds Unix time at which the rate limit of the APIs the service requested resets.")
+	fmt.Fprintln(w, "# TYPE catalog_rate_limit_reset_timestamp_seconds gauge")
+	for _, limit := range rateLimits {
+		fmt.Fprintf(w, "catalog_rate_limit_reset_timestamp_seconds{host=%q} %d\n", limit.Host, limit.Reset)
+	}
+
+	routes := make([]string, 0, len(requestLatency))
+	for route := range requestLatency {
+		routes = append(routes, route)
+	}
+	sort.Strings(routes)
+
+	fmt.Fprintln(w, "# HELP catalog_http_request_duration_seconds Latency of HTTP requests by route.")
+	fmt.Fprintln(w, "# TYPE catalog_http_request_duration_seconds histogram")
+	for _, route := range routes {
+		latency := requestLatency[route]
+		for i, bound := range latencyBuckets {
+			fmt.Fprintf(w, "catalog_http_request_duration_seconds_bucket{route=%q,le=%q} %d\n", route, formatFloat(bound), latency.bucketCounts[i])
+		}
+		fmt.Fprintf(w, "catalog_http_request_duration_seconds_bucket{route=%q,le=\"+Inf\"} %d\n", route, latency.count)
+		fmt.Fprintf(w, "catalog_http_request_duration_seconds_sum{route=%q} %s\n", route, formatFloat(latency.sum))
+		fmt.Fprintf(w, "catalog_http_request_duration_seconds_count{route=%q} %d\n", route, latency.count)
+	}
+}
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+//requestKeys sorts request counters by route and then status code
+type requestKeys []requestKey
+
+func (k requestKeys) Len() int      { return len(k) }
+func (k requestKeys) Swap(i, j int) { k[i], k[j] = k[j], k[i] }
+func (k requestKeys) Less(i, j int) bool {
+	if k[i].route != k[j].route {
+		return k[i].route < k[j].route
+	}
+	return k[i].code < k[j].code
+}
@@ -0,0 +1,311 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/rancher/go-rancher/client"
+	"github.com/rancher/rancher-catalog-service/manager"
+	"github.com/rancher/rancher-catalog-service/model"
+)
+
+//openAPIResource is a body described by the schema of the api registered under an id other than its type name,
+//such as a template version
+type openAPIResource struct {
+	schemaID string
+	body     interface{}
+}
+
+//openAPIOneOf is a body of one of several types, such as the template or the template version a route answers
+//with depending on the id it is given
+type openAPIOneOf []interface{}
+
+//openAPIResponses holds the bodies the routes answer with by status code, nil for a status answered without a body;
+//the routes left out answer 200 with a body the document does not describe
+var openAPIResponses = map[string]map[int]interface{}{
+	"ListTemplates":              {http.StatusOK: model.TemplateCollection{}},
+	"GetTemplatesForCatalog":     {http.StatusOK: model.TemplateCollection{}},
+	"LoadTemplateDetails":        {http.StatusOK: openAPIOneOf{model.Template{}, openAPIResource{"templateVersion", model.Template{}}}},
+	"LoadTemplateVersionDetails": {http.StatusOK: openAPIResource{"templateVersion", model.Template{}}},
+	"GetTemplateDefaultVersion":  {http.StatusOK: openAPIResource{"templateVersion", model.Template{}}},
+	"BatchTemplateVersions":      {http.StatusOK: model.TemplateVersionBatch{}},
+	"ValidateTemplateAnswers":    {http.StatusOK: model.AnswersValidation{}},
+	"ListCatalogs":               {http.StatusOK: manager.CatalogCollection{}},
+	"GetCatalog":                 {http.StatusOK: manager.Catalog{}},
+	"RefreshCatalog":             {http.StatusNoContent: nil, http.StatusAccepted: model.RefreshStatusCollection{}},
+	"RefreshCatalogTemplates":    {http.StatusNoContent: nil, http.StatusAccepted: model.RefreshStatusCollection{}},
+	"GetTemplateHistory":         {http.StatusOK: model.TemplateCommitCollection{}},
+	"GetTemplateLastCommit":      {http.StatusOK: model.TemplateCommit{}},
+	"GetTemplateUpgrades":        {http.StatusOK: model.TemplateUpgrades{}},
+	"GetTemplateDiff":            {http.StatusOK: model.TemplateDiff{}},
+	"GetTemplateCompatibility":   {http.StatusOK: model.TemplateCompatibility{}},
+	"RenderTemplateVersion":      {http.StatusOK: model.RenderedTemplate{}},
+	"ListDiagnostics":            {http.StatusOK: model.TemplateDiagnosticCollection{}},
+	"ListQuestionUsages":         {http.StatusOK: model.QuestionUsageCollection{}},
+	"RecloneCatalogs":            {http.StatusOK: model.RecloneStatusCollection{}},
+	"SelfTest":                   {http.StatusOK: model.SelfTest{}, http.StatusServiceUnavailable: model.SelfTest{}},
+}
+
+//openAPIRequests holds the bodies the routes read
+var openAPIRequests = map[string]interface{}{
+	"ValidateTemplateAnswers": map[string]interface{}{},
+	"RenderTemplateVersion":   map[string]interface{}{},
+	"BatchTemplateVersions":   []string{},
+}
+
+//pathParameter matches the variables of a route pattern, such as {catalogId} or {name:[a-z]+}
+var pathParameter = regexp.MustCompile(`\{([^}:]+)(:[^}]*)?\}`)
+
+//checkOpenAPIRoutes logs the routes the OpenAPI document describes the bodies of but the service does not serve,
+//so that a renamed route is noticed
+func checkOpenAPIRoutes() {
+	served := make(map[string]bool)
+	for _, route := range routes {
+		served[route.Name] = true
+	}
+	for name := range openAPIResponses {
+		if !served[name] {
+			log.Errorf("The OpenAPI document describes the responses of the route %s, which is not served", name)
+		}
+	}
+	for name := range openAPIRequests {
+		if !served[name] {
+			log.Errorf("The OpenAPI document describes the requests of the route %s, which is not served", name)
+		}
+	}
+}
+
+//GetOpenAPI is a handler returning an OpenAPI 3 document of the api, generated from the routes served and the
+//types of the resources so that it follows them
+func GetOpenAPI(w http.ResponseWriter, r *http.Request) {
+	document := newOpenAPIDocument()
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(document); err != nil {
+		requestLog(r).Errorf("Error writing the OpenAPI document: %v", err)
+	}
+}
+
+type openAPIDocument struct {
+	OpenAPI    string                                       `json:"openapi"`
+	Info       map[string]string                            `json:"info"`
+	Paths      map[string]map[string]map[string]interface{} `json:"paths"`
+	Components map[string]map[string]interface{}            `json:"components"`
+}
+
+//newOpenAPIDocument describes the framework endpoints and the application routes
+func newOpenAPIDocument() openAPIDocument {
+	document := openAPIDocument{
+		OpenAPI:    "3.0.0",
+		Info:       map[string]string{"title": "Rancher catalog service", "version": manager.Version},
+		Paths:      make(map[string]map[string]map[string]interface{}),
+		Components: map[string]map[string]interface{}{"schemas": {}},
+	}
+	components := document.Components["schemas"]
+
+	for _, endpoint := range frameworkEndpoints {
+		document.addOperation(endpoint.Name, endpoint.Method, endpoint.Path, components)
+	}
+	for _, route := range routes {
+		document.addOperation(route.Name, route.Method, route.Pattern, components)
+	}
+	return document
+}
+
+//addOperation describes a route, its path and query parameters, the body it reads and the ones it answers with
+func (document openAPIDocument) addOperation(name string, method string, pattern string, components map[string]interface{}) {
+	var parameters []interface{}
+	for _, match := range pathParameter.FindAllStringSubmatch(pattern, -1) {
+		parameters = append(parameters, map[string]interface{}{
+			"name":     match[1],
+			"in":       "path",
+			"required": true,
+			"schema":   map[string]interface{}{"type": "string"},
+		})
+	}
+	if action, ok := actionRoutes[name]; ok {
+		parameters = append(parameters, map[string]interface{}{
+			"name":     "action",
+			"in":       "query",
+			"required": true,
+			"schema":   map[string]interface{}{"type": "string", "enum": []string{action}},
+		})
+	}
+
+	responses := map[string]interface{}{
+		"default": map[string]interface{}{
+			"description": "An error",
+			"content":     jsonContent(bodySchema(model.CatalogError{}, components)),
+		},
+	}
+	if bodies, ok := openAPIResponses[name]; ok {
+		for status, body := range bodies {
+			response := map[string]interface{}{"description": http.StatusText(status)}
+			if body != nil {
+				response["content"] = jsonContent(bodySchema(body, components))
+			}
+			responses[strconv.Itoa(status)] = response
+		}
+	} else {
+		responses[strconv.Itoa(http.StatusOK)] = map[string]interface{}{"description": http.StatusText(http.StatusOK)}
+	}
+
+	operation := map[string]interface{}{
+		"operationId": name,
+		"responses":   responses,
+	}
+	if len(parameters) > 0 {
+		operation["parameters"] = parameters
+	}
+	if body, ok := openAPIRequests[name]; ok {
+		operation["requestBody"] = map[string]interface{}{
+			"required": true,
+			"content":  jsonContent(bodySchema(body, components)),
+		}
+	}
+
+	openAPIPath := pathParameter.ReplaceAllString(pattern, "{$1}")
+	if document.Paths[openAPIPath] == nil {
+		document.Paths[openAPIPath] = make(map[string]map[string]interface{})
+	}
+	document.Paths[openAPIPath][strings.ToLower(method)] = operation
+}
+
+func jsonContent(schema map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{"application/json": map[string]interface{}{"schema": schema}}
+}
+
+//bodySchema returns the schema of a body of the routes
+func bodySchema(body interface{}, components map[string]interface{}) map[string]interface{} {
+	switch body := body.(type) {
+	case openAPIResource:
+		return openAPISchema(reflect.TypeOf(body.body), body.schemaID, components)
+	case openAPIOneOf:
+		var oneOf []interface{}
+		for _, option := range body {
+			oneOf = append(oneOf, bodySchema(option, components))
+		}
+		return map[string]interface{}{"oneOf": oneOf}
+	}
+	return openAPISchema(reflect.TypeOf(body), "", components)
+}
+
+//openAPISchema returns the schema of a type as its json encoding writes it, the structs being described once
+//in the schemas components and referred to. A struct is described under the id of the schema of the api it is
+//registered as, the type name in lower camel case unless schemaID is set, and without the fields that schema
+//leaves out; the other structs are described under their type name
+func openAPISchema(t reflect.Type, schemaID string, components map[string]interface{}) map[string]interface{} {
+	switch t.Kind() {
+	case reflect.Ptr:
+		return openAPISchema(t.Elem(), schemaID, components)
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": openAPISchema(t.Elem(), schemaID, components)}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": openAPISchema(t.Elem(), schemaID, components)}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return structSchema(t, nil, components)
+		}
+		if schemaID == "" {
+			schemaID = strings.ToLower(t.Name()[:1]) + t.Name()[1:]
+		}
+		name := t.Name()
+		var resource *client.Schema
+		if registered, ok := schemas.CheckSchema(schemaID); ok {
+			name = schemaID
+			resource = &registered
+		}
+		if _, ok := components[name]; !ok {
+			//register the name first, so that a type referring to itself ends
+			components[name] = map[string]interface{}{}
+			components[name] = structSchema(t, resource, components)
+		}
+		return map[string]interface{}{"$ref": "#/components/schemas/" + name}
+	}
+	return map[string]interface{}{}
+}
+
+//structSchema describes the fields of a struct under their json names, the fields of an embedded struct being
+//fields of the struct unless the struct has a field of the same name. The fields removed from the schema of the
+//api the struct is registered as are left out
+func structSchema(t reflect.Type, resource *client.Schema, components map[string]interface{}) map[string]interface{} {
+	removed := make(map[string]bool)
+	if resource != nil {
+		//the schema of the type before the fields were removed from it
+		added := (&client.Schemas{}).AddType(resource.Id, reflect.New(t).Elem().Interface())
+		for fieldName := range added.ResourceFields {
+			if _, ok := resource.ResourceFields[fieldName]; !ok {
+				removed[fieldName] = true
+			}
+		}
+	}
+	properties := make(map[string]interface{})
+	addStructProperties(t, resource, removed, properties, components)
+	return map[string]interface{}{"type": "object", "properties": properties}
+}
+
+func addStructProperties(t reflect.Type, resource *client.Schema, removed map[string]bool, properties map[string]interface{}, components map[string]interface{}) {
+	fields := make(map[string]interface{})
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		if field.Anonymous && tag == "" {
+			fieldType := field.Type
+			if fieldType.Kind() == reflect.Ptr {
+				fieldType = fieldType.Elem()
+			}
+			if fieldType.Kind() == reflect.Struct {
+				addStructProperties(fieldType, resource, removed, properties, components)
+				continue
+			}
+		}
+		if field.PkgPath != "" {
+			continue
+		}
+		fieldName := strings.Split(tag, ",")[0]
+		if fieldName == "" {
+			fieldName = field.Name
+		}
+		if removed[fieldName] {
+			continue
+		}
+		fields[fieldName] = openAPISchema(field.Type, referencedSchema(resource, fieldName), components)
+	}
+	for fieldName, schema := range fields {
+		properties[fieldName] = schema
+	}
+}
+
+//referencedSchema returns the id of the schema of the api a field of a resource refers to, such as
+//templateVersion for a field of type map[templateVersion], or "" if it refers to none
+func referencedSchema(resource *client.Schema, fieldName string) string {
+	if resource == nil {
+		return ""
+	}
+	fieldType := resource.ResourceFields[fieldName].Type
+	for _, prefix := range []string{"array[", "map["} {
+		if strings.HasPrefix(fieldType, prefix) && strings.HasSuffix(fieldType, "]") {
+			fieldType = fieldType[len(prefix) : len(fieldType)-1]
+		}
+	}
+	if _, ok := schemas.CheckSchema(fieldType); !ok {
+		return ""
+	}
+	return fieldType
+}
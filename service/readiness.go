@@ -0,0 +1,30 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/rancher/rancher-catalog-service/manager"
+)
+
+//readiness is the response of the readiness check
+type readiness struct {
+	Ready    bool     `json:"ready"`
+	Problems []string `json:"problems"`
+}
+
+//GetReadiness is a handler for route /readiness and answers 200 once the catalogs are loaded, and their remotes
+//reachable with -readinessCheckRemote, or 503 with the problems found
+func GetReadiness(w http.ResponseWriter, r *http.Request) {
+	resp := readiness{Problems: manager.CheckReadiness()}
+	resp.Ready = len(resp.Problems) == 0
+
+	w.Header().Set("Content-Type", "application/json")
+	if !resp.Ready {
+		requestLog(r).Warnf("The service is not ready: %v", resp.Problems)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	if err := json.NewEncoder(w).Encode(&resp); err != nil {
+		requestLog(r).Errorf("Error writing the readiness check: %v", err)
+	}
+}
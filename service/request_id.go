@@ -0,0 +1,64 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/gorilla/context"
+	"github.com/rancher/rancher-catalog-service/manager"
+)
+
+//requestIDHeader carries the id used to trace a request across services
+const requestIDHeader string = "X-Request-ID"
+
+//maxRequestIDLength bounds the length of request ids accepted from clients
+const maxRequestIDLength int = 128
+
+type requestContextKey int
+
+const requestLoggerKey requestContextKey = 0
+
+//withRequestID serves the request through the handler with a logger that tags every line with the request id,
+//the id is taken from the X-Request-ID header or generated, and echoed back in the response
+func withRequestID(handler http.Handler, w http.ResponseWriter, r *http.Request) {
+	requestID := r.Header.Get(requestIDHeader)
+	if !validRequestID(requestID) {
+		requestID = newRequestID()
+	}
+	w.Header().Set(requestIDHeader, requestID)
+
+	context.Set(r, requestLoggerKey, manager.RequestLogger.WithField("requestId", requestID))
+	defer context.Clear(r)
+	handler.ServeHTTP(w, r)
+}
+
+//requestLog returns the logger of the request
+func requestLog(r *http.Request) *log.Entry {
+	if logger, ok := context.Get(r, requestLoggerKey).(*log.Entry); ok {
+		return logger
+	}
+	return log.NewEntry(manager.RequestLogger)
+}
+
+func validRequestID(requestID string) bool {
+	if requestID == "" || len(requestID) > maxRequestIDLength {
+		return false
+	}
+	for _, char := range requestID {
+		if char < '!' || char > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		log.Errorf("Cannot generate a request id, error: %v", err)
+		return "unknown"
+	}
+	return hex.EncodeToString(id)
+}
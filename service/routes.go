@@ -18,7 +18,18 @@ type MuxWrapper struct {
 }
 
 func (httpWrapper *MuxWrapper) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	httpWrapper.Router.ServeHTTP(w, r)
+	withRequestID(http.HandlerFunc(httpWrapper.measure), w, r)
+}
+
+func (httpWrapper *MuxWrapper) measure(w http.ResponseWriter, r *http.Request) {
+	measureRequest(httpWrapper.Router, http.HandlerFunc(httpWrapper.serve), w, r)
+}
+
+func (httpWrapper *MuxWrapper) serve(w http.ResponseWriter, r *http.Request) {
+	if handleCORS(w, r) {
+		return
+	}
+	gzipHandler(httpWrapper.Router, w, r)
 }
 
 //ReturnHTTPError handles sending out CatalogError response
@@ -56,8 +67,11 @@ func NewRouter() *mux.Router {
 	schemas = &client.Schemas{}
 
 	// ApiVersion
-	apiVersion := schemas.AddType("apiVersion", client.Resource{})
-	apiVersion.CollectionMethods = []string{}
+	apiVersionSchema := schemas.AddType("apiVersion", apiVersion{})
+	apiVersionSchema.CollectionMethods = []string{}
+	f0 := apiVersionSchema.ResourceFields["catalogs"]
+	f0.Type = "array[catalog]"
+	apiVersionSchema.ResourceFields["catalogs"] = f0
 
 	// Schema
 	schemas.AddType("schema", client.Schema{})
@@ -65,6 +79,8 @@ func NewRouter() *mux.Router {
 	// Question
 	question := schemas.AddType("question", model.Question{})
 	question.CollectionMethods = []string{}
+	delete(question.ResourceFields, "minSet")
+	delete(question.ResourceFields, "maxSet")
 
 	// Output
 	output := schemas.AddType("output", model.Output{})
@@ -83,6 +99,7 @@ func NewRouter() *mux.Router {
 	delete(template.ResourceFields, "questions")
 	delete(template.ResourceFields, "TemplateVersionRancherVersion")
 	delete(template.ResourceFields, "iconLink")
+	delete(template.ResourceFields, "iconLinkDark")
 	delete(template.ResourceFields, "readmeLink")
 	delete(template.ResourceFields, "projectURL")
 	delete(template.ResourceFields, "version")
@@ -104,6 +121,67 @@ func NewRouter() *mux.Router {
 	catalog := schemas.AddType("catalog", manager.Catalog{})
 	delete(catalog.ResourceFields, "catalogLink")
 
+	// Refresh Status
+	refreshStatus := schemas.AddType("refreshStatus", model.RefreshStatus{})
+	refreshStatus.CollectionMethods = []string{}
+
+	// Template Diagnostic
+	templateDiagnostic := schemas.AddType("templateDiagnostic", model.TemplateDiagnostic{})
+	templateDiagnostic.CollectionMethods = []string{}
+
+	// Question Usage
+	questionUsage := schemas.AddType("questionUsage", model.QuestionUsage{})
+	questionUsage.CollectionMethods = []string{}
+
+	// Template Upgrades
+	templateUpgrades := schemas.AddType("templateUpgrades", model.TemplateUpgrades{})
+	templateUpgrades.CollectionMethods = []string{}
+
+	// Template Diff
+	templateDiff := schemas.AddType("templateDiff", model.TemplateDiff{})
+	templateDiff.CollectionMethods = []string{}
+
+	// Template Compatibility
+	templateCompatibility := schemas.AddType("templateCompatibility", model.TemplateCompatibility{})
+	templateCompatibility.CollectionMethods = []string{}
+	f5 := templateCompatibility.ResourceFields["versions"]
+	f5.Type = "array[versionCompatibility]"
+	templateCompatibility.ResourceFields["versions"] = f5
+	versionCompatibility := schemas.AddType("versionCompatibility", model.VersionCompatibility{})
+	versionCompatibility.CollectionMethods = []string{}
+
+	// Template Version Batch
+	templateVersionBatch := schemas.AddType("templateVersionBatch", model.TemplateVersionBatch{})
+	templateVersionBatch.CollectionMethods = []string{}
+	f6 := templateVersionBatch.ResourceFields["versions"]
+	f6.Type = "map[templateVersion]"
+	templateVersionBatch.ResourceFields["versions"] = f6
+
+	// Template Commit
+	templateCommit := schemas.AddType("templateCommit", model.TemplateCommit{})
+	templateCommit.CollectionMethods = []string{}
+
+	// Answers Validation
+	answersValidation := schemas.AddType("answersValidation", model.AnswersValidation{})
+	answersValidation.CollectionMethods = []string{}
+
+	// Rendered Template
+	renderedTemplate := schemas.AddType("renderedTemplate", model.RenderedTemplate{})
+	renderedTemplate.CollectionMethods = []string{}
+
+	// Self Test
+	selfTest := schemas.AddType("selfTest", model.SelfTest{})
+	selfTest.CollectionMethods = []string{}
+	f4 := selfTest.ResourceFields["checks"]
+	f4.Type = "array[selfTestCheck]"
+	selfTest.ResourceFields["checks"] = f4
+	selfTestCheck := schemas.AddType("selfTestCheck", model.SelfTestCheck{})
+	selfTestCheck.CollectionMethods = []string{}
+
+	// Reclone Status
+	recloneStatus := schemas.AddType("recloneStatus", model.RecloneStatus{})
+	recloneStatus.CollectionMethods = []string{}
+
 	// Error
 	err := schemas.AddType("error", model.CatalogError{})
 	err.CollectionMethods = []string{}
@@ -111,27 +189,42 @@ func NewRouter() *mux.Router {
 	// API framework routes
 	router := mux.NewRouter().StrictSlash(true)
 
-	router.Methods("GET").Path("/").Handler(api.VersionsHandler(schemas, "v1-catalog"))
+	router.Methods("GET").Path("/").Handler(api.ApiHandler(schemas, http.HandlerFunc(GetIndex)))
 	router.Methods("GET").Path("/v1-catalog/schemas").Handler(api.SchemasHandler(schemas))
 	router.Methods("GET").Path("/v1-catalog/schemas/{id}").Handler(api.SchemaHandler(schemas))
-	router.Methods("GET").Path("/v1-catalog").Handler(api.VersionHandler(schemas, "v1-catalog"))
+	router.Methods("GET").Path("/v1-catalog").Handler(api.ApiHandler(schemas, http.HandlerFunc(GetAPIVersion)))
+	router.Methods("GET").Path("/metrics").Name("Metrics").HandlerFunc(WriteMetrics)
+	router.Methods("GET").Path("/readiness").Name("Readiness").HandlerFunc(GetReadiness)
+	router.Methods("GET").Path("/v1-catalog/openapi.json").Name("GetOpenAPI").HandlerFunc(GetOpenAPI)
 
 	// Application routes
 
 	for _, route := range routes {
+		handler := api.ApiHandler(schemas, route.HandlerFunc)
+		if etagRoutes[route.Name] {
+			handler = withETag(handler)
+		}
 		router.
 			Methods(route.Method).
 			Path(route.Pattern).
 			Name(route.Name).
-			Handler(api.ApiHandler(schemas, route.HandlerFunc))
+			Handler(handler)
 	}
 
-	router.GetRoute("RefreshCatalog").Queries("action", "refresh")
-	router.GetRoute("RefreshCatalogTemplates").Queries("action", "refresh")
+	for name, action := range actionRoutes {
+		router.GetRoute(name).Queries("action", action)
+	}
+	checkOpenAPIRoutes()
 
 	return router
 }
 
+//actionRoutes maps the routes that only match requests for an action to the action
+var actionRoutes = map[string]string{
+	"RefreshCatalog":          "refresh",
+	"RefreshCatalogTemplates": "refresh",
+}
+
 var routes = Routes{
 	Route{
 		"ListTemplates",
@@ -143,13 +236,97 @@ var routes = Routes{
 		"LoadTemplateDetails",
 		"GET",
 		"/v1-catalog/templates/{catalog_template_version_Id}",
-		LoadTemplateDetails,
+		limitInFlight(LoadTemplateDetails),
 	},
 	Route{
-		"",
+		"GetTemplateQuestionsSchema",
+		"GET",
+		"/v1-catalog/templates/{catalog_template_version_Id}/questions/schema",
+		limitInFlight(GetTemplateQuestionsSchema),
+	},
+	Route{
+		"HeadTemplateDetails",
+		"HEAD",
+		"/v1-catalog/templates/{catalog_template_version_Id}",
+		limitInFlight(LoadTemplateDetails),
+	},
+	Route{
+		"ValidateTemplateAnswers",
+		"POST",
+		"/v1-catalog/templates/{catalog_template_version_Id}/validate",
+		limitInFlight(ValidateTemplateAnswers),
+	},
+	Route{
+		"GetTemplateScreenshot",
+		"GET",
+		"/v1-catalog/templates/{catalog_template_version_Id}/screenshots/{file_name}",
+		GetTemplateScreenshot,
+	},
+	Route{
+		"GetTemplateDefaultAnswers",
+		"GET",
+		"/v1-catalog/templates/{catalog_template_version_Id}/answers",
+		limitInFlight(GetTemplateDefaultAnswers),
+	},
+	Route{
+		"RenderTemplateVersion",
+		"POST",
+		"/v1-catalog/templates/{catalog_template_version_Id}/render",
+		limitInFlight(RenderTemplateVersion),
+	},
+	Route{
+		"GetTemplateUpgrades",
+		"GET",
+		"/v1-catalog/templates/{catalog_template_Id}/upgrades",
+		GetTemplateUpgrades,
+	},
+	Route{
+		"GetTemplateDiff",
+		"GET",
+		"/v1-catalog/templates/{catalog_template_Id}/diff",
+		limitInFlight(GetTemplateDiff),
+	},
+	Route{
+		"GetTemplateHistory",
+		"GET",
+		"/v1-catalog/templates/{catalog_template_version_Id}/history",
+		GetTemplateHistory,
+	},
+	Route{
+		"GetTemplateConfigFile",
+		"GET",
+		"/v1-catalog/templates/{catalog_template_version_Id}/config.yml",
+		limitInFlight(GetTemplateConfigFile),
+	},
+	Route{
+		"GetTemplateDefaultVersion",
+		"GET",
+		"/v1-catalog/templates/{catalog_template_Id}/default",
+		limitInFlight(GetTemplateDefaultVersion),
+	},
+	Route{
+		"GetTemplateCompatibility",
+		"GET",
+		"/v1-catalog/templates/{catalog_template_Id}/compatibility",
+		limitInFlight(GetTemplateCompatibility),
+	},
+	Route{
+		"BatchTemplateVersions",
+		"POST",
+		"/v1-catalog/templates/{catalog_template_Id}/versions:batch",
+		limitInFlight(BatchTemplateVersions),
+	},
+	Route{
+		"LoadTemplateVersionDetails",
 		"GET",
 		"/v1-catalog/templateversions/{catalog_template_version_Id}",
-		LoadTemplateDetails,
+		limitInFlight(LoadTemplateDetails),
+	},
+	Route{
+		"HeadTemplateVersionDetails",
+		"HEAD",
+		"/v1-catalog/templateversions/{catalog_template_version_Id}",
+		limitInFlight(LoadTemplateDetails),
 	},
 	Route{
 		"RefreshCatalog",
@@ -176,6 +353,36 @@ var routes = Routes{
 		"/v1-catalog/catalogs/{catalogId}",
 		GetCatalog,
 	},
+	Route{
+		"ListDiagnostics",
+		"GET",
+		"/v1-catalog/admin/diagnostics",
+		ListDiagnostics,
+	},
+	Route{
+		"ListQuestionUsages",
+		"GET",
+		"/v1-catalog/admin/questions",
+		limitInFlight(ListQuestionUsages),
+	},
+	Route{
+		"RecloneCatalogs",
+		"POST",
+		"/v1-catalog/admin/reclone",
+		RecloneCatalogs,
+	},
+	Route{
+		"SelfTest",
+		"GET",
+		"/v1-catalog/admin/selftest",
+		limitInFlight(SelfTest),
+	},
+	Route{
+		"GetTemplateLastCommit",
+		"GET",
+		"/v1-catalog/admin/templates/{catalog_template_version_Id}/lastcommit",
+		GetTemplateLastCommit,
+	},
 	Route{
 		"GetTemplatesForCatalog",
 		"GET",
@@ -0,0 +1,68 @@
+package service
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/rancher/rancher-catalog-service/model"
+)
+
+//templateOrders are the orders the template lists can be sorted in with the sort parameter
+var templateOrders = map[string]func(a *model.Template, b *model.Template) bool{
+	"name": func(a *model.Template, b *model.Template) bool {
+		return strings.ToLower(a.Name) < strings.ToLower(b.Name)
+	},
+	"category": func(a *model.Template, b *model.Template) bool {
+		return strings.ToLower(a.Category) < strings.ToLower(b.Category)
+	},
+	//templates with a higher weight are featured first
+	"weight": func(a *model.Template, b *model.Template) bool {
+		return a.Weight > b.Weight
+	},
+}
+
+//templatesBy sorts templates in one of the templateOrders, breaking ties by name and then by id
+type templatesBy struct {
+	templates []model.Template
+	less      func(a *model.Template, b *model.Template) bool
+}
+
+func (t templatesBy) Len() int      { return len(t.templates) }
+func (t templatesBy) Swap(i, j int) { t.templates[i], t.templates[j] = t.templates[j], t.templates[i] }
+func (t templatesBy) Less(i, j int) bool {
+	a, b := &t.templates[i], &t.templates[j]
+	if t.less(a, b) {
+		return true
+	}
+	if t.less(b, a) {
+		return false
+	}
+	if nameA, nameB := strings.ToLower(a.Name), strings.ToLower(b.Name); nameA != nameB {
+		return nameA < nameB
+	}
+	return a.Id < b.Id
+}
+
+//getTemplateOrder reads the sort parameter, nil means the templates are not sorted
+func getTemplateOrder(r *http.Request) (func(a *model.Template, b *model.Template) bool, error) {
+	sortBy := r.URL.Query().Get("sort")
+	if sortBy == "" {
+		return nil, nil
+	}
+	less, ok := templateOrders[strings.ToLower(sortBy)]
+	if !ok {
+		requestLog(r).Errorf("Error loading the passed sort order: %s", sortBy)
+		return nil, fmt.Errorf("Invalid value for sort: %s, expected one of name, category, weight", sortBy)
+	}
+	return less, nil
+}
+
+//sortTemplates sorts the templates in the given order, if any
+func sortTemplates(templates []model.Template, less func(a *model.Template, b *model.Template) bool) {
+	if less == nil {
+		return
+	}
+	sort.Sort(templatesBy{templates: templates, less: less})
+}
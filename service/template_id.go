@@ -0,0 +1,49 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+)
+
+//splitTemplateID splits a catalogId:templateId[:versionId] id into its parts, expecting between minParts and
+//maxParts of them; the error tells why the id is malformed rather than naming a template that does not exist
+func splitTemplateID(templateIDString string, minParts int, maxParts int) ([]string, error) {
+	pathTokens := strings.Split(templateIDString, ":")
+	if len(pathTokens) < minParts || len(pathTokens) > maxParts {
+		if minParts == maxParts {
+			return nil, fmt.Errorf("expected %d parts separated by ':', got %d", minParts, len(pathTokens))
+		}
+		return nil, fmt.Errorf("expected %d to %d parts separated by ':', got %d", minParts, maxParts, len(pathTokens))
+	}
+
+	for i, token := range pathTokens {
+		names := []string{token}
+		if i == 1 && strings.Contains(token, "*") {
+			//a template of a prefixed templates folder, such as k8s*name
+			names = strings.Split(token, "*")
+			if len(names) != 2 {
+				return nil, fmt.Errorf("template %q has more than one '*' separator", token)
+			}
+		}
+		for _, name := range names {
+			if name == "" {
+				return nil, fmt.Errorf("empty part in %q", token)
+			}
+			if err := checkIDPart(name); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return pathTokens, nil
+}
+
+//checkIDPart checks that a part of a template id, or a version given apart from it, names a folder of the catalog
+func checkIDPart(name string) error {
+	if name == "" {
+		return fmt.Errorf("empty part")
+	}
+	if name == "." || name == ".." || strings.ContainsAny(name, "/\\") {
+		return fmt.Errorf("invalid part %q", name)
+	}
+	return nil
+}